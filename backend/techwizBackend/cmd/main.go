@@ -5,16 +5,22 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"log"
+	"techwizBackend/pkg/config"
+	"techwizBackend/pkg/geo"
+	"techwizBackend/pkg/notify"
 	"techwizBackend/pkg/repository"
 	"techwizBackend/pkg/service"
 	"techwizBackend/pkg/transport/http"
 	"techwizBackend/pkg/transport/ws"
+	_ "time/tzdata" // the alpine image has no timezone database
 )
 
 func main() {
 	// Init Web Server
 	e := echo.New()
 	e.Use(middleware.CORS())
+	// Load settings
+	cfg := config.New()
 	// Init connection MongoDB
 	db := repository.New()
 	defer func() {
@@ -23,6 +29,10 @@ func main() {
 			panic(err)
 		}
 	}()
+	if err := repository.CreateIndexes(db); err != nil {
+		log.Println(err)
+		panic(err)
+	}
 	// Init repositories
 	authRepository := repository.NewAuthRepository(db)
 	chatRepository := repository.NewChatRepository(db)
@@ -31,14 +41,37 @@ func main() {
 	messageRepository := repository.NewMessageRepository(db)
 	requestRepository := repository.NewRequestRepository(db)
 	statisticRepository := repository.NewStatisticRepository(db)
+	quoteRepository := repository.NewQuoteRepository(db)
+	documentRepository := repository.NewDocumentRepository(db)
+	reviewRepository := repository.NewReviewRepository(db)
+	tierRepository := repository.NewTierRepository(db)
+	routeRepository := repository.NewRouteRepository(db)
+	slaRepository := repository.NewSlaRepository(db)
+	jobRepository := repository.NewJobRepository(db)
+	notificationRepository := repository.NewNotificationRepository(db)
+	webhookRepository := repository.NewWebhookRepository(db)
+	apiKeyRepository := repository.NewApiKeyRepository(db)
 	// Create services
+	notificationService := service.NewNotificationService(notificationRepository)
 	authService := service.NewAuthService(authRepository, userRepository)
-	chatService := service.NewChatService(chatRepository, userRepository)
+	chatService := service.NewChatService(chatRepository, userRepository, messageRepository)
 	categoryService := service.NewCategoryService(categoryRepository, chatRepository)
-	userService := service.NewUserService(userRepository, chatRepository, requestRepository)
-	requestService := service.NewRequestService(requestRepository, userRepository)
-	messageService := service.NewMessageService(messageRepository, chatRepository, userRepository)
+	userService := service.NewUserService(userRepository, chatRepository, requestRepository, notificationService)
+	documentService := service.NewDocumentService(documentRepository, requestRepository)
+	scheduleService := service.NewScheduleService(userRepository, requestRepository, categoryRepository, cfg)
+	geoService := service.NewGeoService(requestRepository, userRepository, geo.New(cfg))
+	jobService := service.NewJobService(jobRepository, userRepository, cfg)
+	reminderService := service.NewReminderService(requestRepository, jobService, notify.New(cfg.NotifyChannel), cfg)
+	webhookService := service.NewWebhookService(webhookRepository, userRepository, jobService, cfg)
+	routeService := service.NewRouteService(routeRepository, requestRepository, userRepository, scheduleService, cfg)
+	requestService := service.NewRequestService(requestRepository, userRepository, quoteRepository, documentService, scheduleService, geoService, routeService, reminderService, notificationService, webhookService, cfg)
+	messageService := service.NewMessageService(messageRepository, chatRepository, userRepository, cfg)
 	statisticService := service.NewStatisticService(statisticRepository)
+	quoteService := service.NewQuoteService(quoteRepository, requestRepository, userRepository, requestService)
+	reviewService := service.NewReviewService(reviewRepository, requestRepository, userRepository)
+	tierService := service.NewTierService(tierRepository, userRepository, requestRepository, cfg)
+	apiKeyService := service.NewApiKeyService(apiKeyRepository, userRepository, cfg)
+	slaService := service.NewSlaService(slaRepository, requestRepository, userRepository, notificationService, cfg)
 	// Create general service
 	services := service.NewServices(
 		authService,
@@ -48,10 +81,43 @@ func main() {
 		chatService,
 		messageService,
 		statisticService,
+		quoteService,
+		documentService,
+		reviewService,
+		tierService,
+		scheduleService,
+		geoService,
+		routeService,
+		slaService,
+		jobService,
+		notificationService,
+		webhookService,
+		apiKeyService,
 	)
 	// Init hub websocket
-	hub := ws.NewHub(services)
+	bus := ws.NewBus(cfg, db, chatService.GetRecipient)
+	hub := ws.NewHub(services, cfg, bus)
 	go hub.Run()
+	// notifications, read receipts and message changes are pushed over the hub, it knows who is online
+	notificationService.SetPusher(hub)
+	chatService.SetPusher(hub)
+	messageService.SetPusher(hub)
+	userService.SetPresence(hub)
+	// Start background jobs
+	jobService.Register(service.JobTierEvaluate, tierService.EvaluateJob)
+	jobService.Register(service.JobSlaCheck, slaService.CheckJob)
+	jobService.Register(service.JobReminder, reminderService.SendJob)
+	jobService.Register(service.JobWebhook, webhookService.DeliverJob)
+	for jobType, spec := range map[string]string{
+		service.JobTierEvaluate: cfg.TierSchedule,
+		service.JobSlaCheck:     cfg.SlaSchedule,
+	} {
+		if err := jobService.Recurring(jobType, spec); err != nil {
+			log.Println(err)
+			panic(err)
+		}
+	}
+	go jobService.Run()
 	// Init handler
 	websocket := ws.New(hub)
 	http.New(e, services, websocket)
@@ -4,10 +4,12 @@ go 1.24
 
 require (
 	github.com/dongri/phonenumber v0.1.12
+	github.com/go-pdf/fpdf v0.9.0
 	github.com/gorilla/websocket v1.5.3
 	github.com/joho/godotenv v1.5.1
 	github.com/labstack/echo/v4 v4.13.4
 	go.mongodb.org/mongo-driver/v2 v2.2.2
+	golang.org/x/image v0.27.0
 )
 
 require (
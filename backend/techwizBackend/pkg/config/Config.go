@@ -0,0 +1,269 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Config holds the business settings that are read from the environment.
+// Every value has a default, so an empty environment is valid
+type Config struct {
+	// commission by line item type, e.g. LINE_COMMISSION="parts=0.1,other=0.3"
+	// types that are not listed use the commission of the master
+	LineCommission map[string]float64
+
+	// thresholds of the automatic tier evaluation
+	SeniorTier    TierThreshold
+	PremiumTier   TierThreshold
+	TierAutoApply bool          // false = decisions are only proposed to the admin
+	TierSchedule  string        // cron of the evaluation job
+	TierCooldown  time.Duration // a tier rejected by the admin is not proposed again for this time
+
+	// schedule of masters
+	Location           *time.Location // company timezone of working hours
+	DefaultWorkStart   string         // used when the master has no schedule
+	DefaultWorkEnd     string
+	DefaultJobDuration time.Duration // used when the category has no duration
+
+	// geocoding of addresses
+	Geocoder      string // "none", "gazetteer" or "nominatim"
+	GazetteerFile string // json {"address": [lng, lat]}
+	NominatimURL  string
+
+	// route planning, travel time is estimated by straight-line distance
+	RouteSpeed  float64       // km/h
+	RouteWindow time.Duration // how late the master may arrive after the request datetime
+
+	// check-in is rejected farther than the radius from the request, 0 = not checked
+	CheckInRadius float64 // meters
+
+	// default limits of statuses 1 and 2 when no SLA policy matches
+	SlaWaiting       time.Duration
+	SlaAppointed     time.Duration
+	SlaPremiumFactor float64 // premium requests without own policy get limit * factor
+	SlaSchedule      string  // cron of the check job
+
+	// background jobs
+	JobPoll        time.Duration // how often due jobs are looked for
+	JobLease       time.Duration // a job running longer is taken over by another instance
+	JobMaxAttempts int
+	JobBackoff     time.Duration // delay of the first retry, doubled on every attempt
+	JobMaxBackoff  time.Duration
+	JobWorkers     int // jobs run at the same time, a single type takes at most JobWorkers-1 of them
+
+	// appointment reminders before the request datetime, e.g. REMINDER_OFFSETS="24h,1h"
+	ReminderOffsets []time.Duration
+	NotifyChannel   string // "log"
+
+	WebhookTimeout time.Duration
+
+	ApiKeyRateLimit int // requests per minute of a partner key without its own limit
+
+	MessageEditWindow time.Duration // the sender can edit the message for this time
+
+	WsSendBuffer   int // envelopes queued for a client before it is dropped as slow
+	WsWriteTimeout time.Duration
+	WsPingInterval time.Duration
+	WsPongWait     time.Duration // the connection is dead without a pong or a message in this time
+	WsMaxMessage   int64         // bytes of a client frame
+	WsBus          string        // "memory" for a single instance or "mongo"
+	WsPresenceSync time.Duration // online users are resent to the other instances
+	WsSyncLimit    int           // messages in a single sync
+	WsTypingLimit  time.Duration // interval between typing events of a chat from a connection
+}
+
+// TierThreshold is the minimum performance of the master for the tier
+type TierThreshold struct {
+	Completed  int64
+	Rating     float64
+	MinReviews int // rating is ignored with fewer reviews
+	CancelRate float64
+	TenureDays int
+}
+
+func New() *Config {
+	// .env is optional, docker passes variables directly
+	_ = godotenv.Load()
+
+	return &Config{
+		LineCommission: getRates("LINE_COMMISSION", map[string]float64{}),
+		SeniorTier: TierThreshold{
+			Completed:  int64(getInt("TIER_SENIOR_COMPLETED", 20)),
+			Rating:     getFloat("TIER_SENIOR_RATING", 4.5),
+			MinReviews: getInt("TIER_SENIOR_MIN_REVIEWS", 5),
+			CancelRate: getFloat("TIER_SENIOR_CANCEL_RATE", 0.15),
+			TenureDays: getInt("TIER_SENIOR_TENURE_DAYS", 90),
+		},
+		PremiumTier: TierThreshold{
+			Completed:  int64(getInt("TIER_PREMIUM_COMPLETED", 50)),
+			Rating:     getFloat("TIER_PREMIUM_RATING", 4.8),
+			MinReviews: getInt("TIER_PREMIUM_MIN_REVIEWS", 15),
+			CancelRate: getFloat("TIER_PREMIUM_CANCEL_RATE", 0.1),
+			TenureDays: getInt("TIER_PREMIUM_TENURE_DAYS", 180),
+		},
+		TierAutoApply: getBool("TIER_AUTO_APPLY", false),
+		TierSchedule:  getString("TIER_SCHEDULE", "@daily"),
+		TierCooldown:  getDuration("TIER_REJECT_COOLDOWN", 30*24*time.Hour),
+
+		Location:           getLocation("TIMEZONE", "Europe/Moscow"),
+		DefaultWorkStart:   getString("WORK_START", "09:00"),
+		DefaultWorkEnd:     getString("WORK_END", "18:00"),
+		DefaultJobDuration: getDuration("JOB_DURATION", time.Hour),
+
+		Geocoder:      getString("GEOCODER", "none"),
+		GazetteerFile: getString("GAZETTEER_FILE", ""),
+		NominatimURL:  getString("NOMINATIM_URL", "https://nominatim.openstreetmap.org"),
+
+		RouteSpeed:  getFloat("ROUTE_SPEED", 25),
+		RouteWindow: getDuration("ROUTE_WINDOW", time.Hour),
+
+		CheckInRadius: getFloat("CHECKIN_RADIUS", 500),
+
+		SlaWaiting:       getDuration("SLA_WAITING", 2*time.Hour),
+		SlaAppointed:     getDuration("SLA_APPOINTED", 24*time.Hour),
+		SlaPremiumFactor: getFloat("SLA_PREMIUM_FACTOR", 0.5),
+		SlaSchedule:      getString("SLA_SCHEDULE", "@every 5m"),
+
+		JobPoll:        getDuration("JOB_POLL", 5*time.Second),
+		JobLease:       getDuration("JOB_LEASE", 5*time.Minute),
+		JobMaxAttempts: getInt("JOB_MAX_ATTEMPTS", 5),
+		JobBackoff:     getDuration("JOB_BACKOFF", 30*time.Second),
+		JobMaxBackoff:  getDuration("JOB_MAX_BACKOFF", time.Hour),
+		JobWorkers:     getInt("JOB_WORKERS", 4),
+
+		ReminderOffsets: getDurations("REMINDER_OFFSETS", []time.Duration{24 * time.Hour, time.Hour}),
+		NotifyChannel:   getString("NOTIFY_CHANNEL", "log"),
+
+		WebhookTimeout: getDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+
+		ApiKeyRateLimit: getInt("API_KEY_RATE_LIMIT", 60),
+
+		MessageEditWindow: getDuration("MESSAGE_EDIT_WINDOW", 15*time.Minute),
+
+		WsSendBuffer:   getInt("WS_SEND_BUFFER", 256),
+		WsWriteTimeout: getDuration("WS_WRITE_TIMEOUT", 10*time.Second),
+		WsPingInterval: getDuration("WS_PING_INTERVAL", 30*time.Second),
+		WsPongWait:     getDuration("WS_PONG_WAIT", 60*time.Second),
+		WsMaxMessage:   int64(getInt("WS_MAX_MESSAGE", 16384)),
+		WsBus:          getString("WS_BUS", "memory"),
+		WsPresenceSync: getDuration("WS_PRESENCE_SYNC", 30*time.Second),
+		WsSyncLimit:    getInt("WS_SYNC_LIMIT", 200),
+		WsTypingLimit:  getDuration("WS_TYPING_THROTTLE", 3*time.Second),
+	}
+}
+
+func getString(key string, def string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return def
+}
+
+func getInt(key string, def int) int {
+	value := getString(key, "")
+	if value == "" {
+		return def
+	}
+	res, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("config: invalid %s=%q, using %v", key, value, def)
+		return def
+	}
+	return res
+}
+
+func getFloat(key string, def float64) float64 {
+	value := getString(key, "")
+	if value == "" {
+		return def
+	}
+	res, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("config: invalid %s=%q, using %v", key, value, def)
+		return def
+	}
+	return res
+}
+
+func getBool(key string, def bool) bool {
+	value := getString(key, "")
+	if value == "" {
+		return def
+	}
+	res, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("config: invalid %s=%q, using %v", key, value, def)
+		return def
+	}
+	return res
+}
+
+// getDuration parses values like "90s", "15m" or "24h"
+func getDuration(key string, def time.Duration) time.Duration {
+	value := getString(key, "")
+	if value == "" {
+		return def
+	}
+	res, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("config: invalid %s=%q, using %v", key, value, def)
+		return def
+	}
+	return res
+}
+
+// getDurations parses a comma separated list of durations
+func getDurations(key string, def []time.Duration) []time.Duration {
+	value := getString(key, "")
+	if value == "" {
+		return def
+	}
+	var res []time.Duration
+	for _, item := range strings.Split(value, ",") {
+		duration, err := time.ParseDuration(strings.TrimSpace(item))
+		if err != nil || duration <= 0 {
+			log.Printf("config: invalid %s=%q, using %v", key, value, def)
+			return def
+		}
+		res = append(res, duration)
+	}
+	return res
+}
+
+func getLocation(key string, def string) *time.Location {
+	value := getString(key, def)
+	loc, err := time.LoadLocation(value)
+	if err != nil {
+		log.Printf("config: invalid %s=%q, using UTC", key, value)
+		return time.UTC
+	}
+	return loc
+}
+
+// getRates parses "key=value,key=value" into a map
+func getRates(key string, def map[string]float64) map[string]float64 {
+	value := getString(key, "")
+	if value == "" {
+		return def
+	}
+	res := make(map[string]float64)
+	for _, pair := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			log.Printf("config: invalid pair %q in %s", pair, key)
+			continue
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			log.Printf("config: invalid rate %q in %s", pair, key)
+			continue
+		}
+		res[strings.TrimSpace(k)] = rate
+	}
+	return res
+}
@@ -0,0 +1,148 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule returns the next run after the given time
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+// Parse supports "@every <duration>", "@hourly", "@daily", "@weekly"
+// and five fields "minute hour day-of-month month day-of-week"
+// with "*", lists "1,15", ranges "1-5" and steps "*/10"
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	switch spec {
+	case "@hourly":
+		spec = "0 * * * *"
+	case "@daily":
+		spec = "0 0 * * *"
+	case "@weekly":
+		spec = "0 0 * * 0"
+	}
+
+	if strings.HasPrefix(spec, "@every ") {
+		interval, err := time.ParseDuration(strings.TrimPrefix(spec, "@every "))
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid interval in %q", spec)
+		}
+		return every(interval), nil
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron %q, 5 fields expected", spec)
+	}
+
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+	var s fields5
+	for i, field := range fields {
+		set, err := parseField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron %q: %w", spec, err)
+		}
+		s.set(i, set)
+	}
+	s.anyDom = fields[2] == "*"
+	s.anyDow = fields[4] == "*"
+	return s, nil
+}
+
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+type fields5 struct {
+	minute, hour, dom, month, dow map[int]bool
+	anyDom, anyDow                bool
+}
+
+// fields are assigned by index
+func (s *fields5) set(i int, value map[int]bool) {
+	switch i {
+	case 0:
+		s.minute = value
+	case 1:
+		s.hour = value
+	case 2:
+		s.dom = value
+	case 3:
+		s.month = value
+	case 4:
+		s.dow = value
+	}
+}
+
+// Next searches minute by minute, a year of minutes is the upper bound
+func (s fields5) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	for limit := 0; limit < 366*24*60; limit++ {
+		if s.month[int(t.Month())] && s.day(t) && s.hour[t.Hour()] && s.minute[t.Minute()] {
+			return t
+		}
+		t = t.Add(time.Minute)
+	}
+	return t
+}
+
+// day follows the cron rule: if both day fields are restricted, either may match
+func (s fields5) day(t time.Time) bool {
+	dom, dow := s.dom[t.Day()], s.dow[int(t.Weekday())]
+	switch {
+	case s.anyDom && s.anyDow:
+		return true
+	case s.anyDom:
+		return dow
+	case s.anyDow:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+func parseField(field string, min int, max int) (map[int]bool, error) {
+	set := map[int]bool{}
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			value, err := strconv.Atoi(part[i+1:])
+			if err != nil || value <= 0 {
+				return nil, fmt.Errorf("invalid step %q", part)
+			}
+			step = value
+			part = part[:i]
+		}
+
+		from, to := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			value, err := strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", part)
+			}
+			from, to = value, value
+			if len(bounds) == 2 {
+				if to, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, fmt.Errorf("invalid range %q", part)
+				}
+			} else if step > 1 {
+				to = max
+			}
+		}
+		if from < min || to > max || from > to {
+			return nil, fmt.Errorf("value %q is out of range %d-%d", part, min, max)
+		}
+
+		for value := from; value <= to; value += step {
+			set[value] = true
+		}
+	}
+	return set, nil
+}
@@ -0,0 +1,196 @@
+package document
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"techwizBackend/pkg/models"
+	"text/template"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// Go fonts are embedded, so rendering does not depend on the system fonts and cgo
+const font = "Go"
+
+func DefaultTemplate() models.DocumentTemplate {
+	return models.DocumentTemplate{
+		CompanyName:  "TechPower",
+		InvoiceTitle: "Счёт № {{.Number}} от {{.Date}}",
+		ActTitle:     "Акт выполненных работ № {{.Number}} от {{.Date}}",
+		Footer:       "Работы выполнены полностью и в срок. Заказчик претензий по объёму, качеству и срокам не имеет.",
+	}
+}
+
+// sample fills every field, so a valid template does not fail on empty data
+var sample = models.DocumentData{
+	Number:   "0000000A",
+	Date:     "01.01.2025",
+	Customer: "Иванов Иван",
+	Phone:    "+79990000000",
+	Address:  "Москва, ул. Тверская, 1",
+	Problem:  "Не включается",
+	Category: "Ремонт техники",
+	Master:   "Петров Пётр",
+	Items:    []models.LineItem{{Type: models.LineLabour, Description: "Диагностика", Quantity: 1, UnitPrice: 1000}},
+	Total:    1000,
+}
+
+// Validate checks that the text templates can be parsed and executed
+func Validate(tmpl models.DocumentTemplate) error {
+	for _, text := range []string{tmpl.InvoiceTitle, tmpl.ActTitle, tmpl.Footer} {
+		if _, err := execute(text, sample); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Render builds the PDF of the given kind
+func Render(kind string, tmpl models.DocumentTemplate, data models.DocumentData) ([]byte, error) {
+	titleText := tmpl.InvoiceTitle
+	if kind == models.Act {
+		titleText = tmpl.ActTitle
+	}
+	title, err := execute(titleText, data)
+	if err != nil {
+		return nil, err
+	}
+	footer, err := execute(tmpl.Footer, data)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(font, "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes(font, "B", gobold.TTF)
+	pdf.SetTitle(title, true)
+	pdf.SetMargins(15, 15, 15)
+	pdf.AddPage()
+	width, _ := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	content := width - left - right
+
+	// Company
+	pdf.SetFont(font, "B", 14)
+	pdf.CellFormat(content, 7, tmpl.CompanyName, "", 1, "L", false, 0, "")
+	pdf.SetFont(font, "", 9)
+	for _, line := range []string{tmpl.CompanyAddress, tmpl.CompanyPhone, tmpl.CompanyDetails} {
+		if line != "" {
+			pdf.MultiCell(content, 4.5, line, "", "L", false)
+		}
+	}
+	pdf.Ln(6)
+
+	// Title
+	pdf.SetFont(font, "B", 13)
+	pdf.MultiCell(content, 7, title, "", "C", false)
+	pdf.Ln(4)
+
+	// Request
+	pdf.SetFont(font, "", 10)
+	for _, row := range [][2]string{
+		{"Заказчик", data.Customer},
+		{"Телефон", data.Phone},
+		{"Адрес", data.Address},
+		{"Категория", data.Category},
+		{"Мастер", data.Master},
+		{"Проблема", data.Problem},
+	} {
+		if row[1] == "" {
+			continue
+		}
+		pdf.SetFont(font, "B", 10)
+		pdf.CellFormat(30, 5.5, row[0]+":", "", 0, "L", false, 0, "")
+		pdf.SetFont(font, "", 10)
+		pdf.MultiCell(content-30, 5.5, row[1], "", "L", false)
+	}
+	pdf.Ln(4)
+
+	// Items
+	columns := []struct {
+		name  string
+		width float64
+		align string
+	}{
+		{"№", 10, "C"},
+		{"Наименование", content - 10 - 22 - 20 - 28 - 28, "L"},
+		{"Тип", 22, "C"},
+		{"Кол-во", 20, "R"},
+		{"Цена, руб.", 28, "R"},
+		{"Сумма, руб.", 28, "R"},
+	}
+	pdf.SetFont(font, "B", 9)
+	for _, col := range columns {
+		pdf.CellFormat(col.width, 7, col.name, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont(font, "", 9)
+	for i, item := range data.Items {
+		values := []string{
+			fmt.Sprint(i + 1),
+			item.Description,
+			lineType(item.Type),
+			formatNumber(item.Quantity),
+			formatMoney(item.UnitPrice),
+			formatMoney(item.Total()),
+		}
+		for j, col := range columns {
+			pdf.CellFormat(col.width, 6, values[j], "1", 0, col.align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.SetFont(font, "B", 10)
+	pdf.CellFormat(content-28, 7, "Итого:", "", 0, "R", false, 0, "")
+	pdf.CellFormat(28, 7, formatMoney(data.Total), "", 1, "R", false, 0, "")
+	pdf.Ln(6)
+
+	// Footer
+	pdf.SetFont(font, "", 10)
+	if footer != "" {
+		pdf.MultiCell(content, 5, footer, "", "L", false)
+		pdf.Ln(10)
+	}
+	if kind == models.Act {
+		half := content / 2
+		pdf.CellFormat(half, 6, "Исполнитель ____________________", "", 0, "L", false, 0, "")
+		pdf.CellFormat(half, 6, "Заказчик ____________________", "", 1, "L", false, 0, "")
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func execute(text string, data models.DocumentData) (string, error) {
+	tmpl, err := template.New("document").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid template %q: %w", text, err)
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("invalid template %q: %w", text, err)
+	}
+	return buf.String(), nil
+}
+
+func lineType(t string) string {
+	switch t {
+	case models.LineParts:
+		return "Запчасти"
+	default:
+		return "Работа"
+	}
+}
+
+func formatNumber(value float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", value), "0"), ".")
+}
+
+func formatMoney(value float64) string {
+	return fmt.Sprintf("%.2f", value)
+}
@@ -0,0 +1,66 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"techwizBackend/pkg/models"
+	"unicode"
+)
+
+// Gazetteer is an offline geocoder over a fixed list of places.
+// An address matches the longest known place that it contains,
+// so "ул. Ленина, 5, кв. 12" finds "ул. Ленина, 5" or at least "ул. Ленина"
+type Gazetteer struct {
+	places map[string]*models.Point
+}
+
+// NewGazetteer builds the gazetteer from places, coordinates are [lng, lat]
+func NewGazetteer(places map[string][2]float64) *Gazetteer {
+	g := Gazetteer{places: map[string]*models.Point{}}
+	for address, coordinates := range places {
+		g.places[normalize(address)] = models.NewPoint(coordinates[1], coordinates[0])
+	}
+	return &g
+}
+
+// LoadGazetteer reads the json file {"address": [lng, lat]}
+func LoadGazetteer(path string) (*Gazetteer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gazetteer: %w", err)
+	}
+
+	var places map[string][2]float64
+	if err := json.Unmarshal(data, &places); err != nil {
+		return nil, fmt.Errorf("invalid gazetteer: %w", err)
+	}
+	return NewGazetteer(places), nil
+}
+
+func (g Gazetteer) Geocode(address string) (*models.Point, error) {
+	address = normalize(address)
+	if point, ok := g.places[address]; ok {
+		return point, nil
+	}
+
+	var best string
+	for place := range g.places {
+		if len(place) > len(best) && strings.Contains(" "+address+" ", " "+place+" ") {
+			best = place
+		}
+	}
+	if best == "" {
+		return nil, ErrNotFound
+	}
+	return g.places[best], nil
+}
+
+// normalize lowercases the address and leaves only words separated by single spaces
+func normalize(address string) string {
+	words := strings.FieldsFunc(strings.ToLower(address), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,96 @@
+package geo
+
+import (
+	"errors"
+	"math"
+	"techwizBackend/pkg/models"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{"ул. Ленина, 5", "ул ленина 5"},
+		{"  УЛ.  ЛЕНИНА,5 ", "ул ленина 5"},
+		{"пр-т Мира, д.10/2", "пр т мира д 10 2"},
+		{"Москва", "москва"},
+		{"", ""},
+		{".,;", ""},
+	}
+	for _, tt := range tests {
+		if got := normalize(tt.address); got != tt.want {
+			t.Errorf("normalize(%q) = %q, want %q", tt.address, got, tt.want)
+		}
+	}
+}
+
+func TestGazetteerGeocode(t *testing.T) {
+	g := NewGazetteer(map[string][2]float64{
+		"ул. Ленина":    {37.1, 55.1},
+		"ул. Ленина, 5": {37.5, 55.5},
+		"пр. Мира":      {30.3, 59.9},
+	})
+
+	tests := []struct {
+		name    string
+		address string
+		want    [2]float64 // [lng, lat]
+		err     error
+	}{
+		{"exact", "ул. Ленина, 5", [2]float64{37.5, 55.5}, nil},
+		{"case and punctuation", "УЛ ЛЕНИНА 5", [2]float64{37.5, 55.5}, nil},
+		{"longest place wins", "г. Москва, ул. Ленина, 5, кв. 12", [2]float64{37.5, 55.5}, nil},
+		{"street without house", "ул. Ленина, 7", [2]float64{37.1, 55.1}, nil},
+		{"other street", "пр. Мира, 1", [2]float64{30.3, 59.9}, nil},
+		{"whole words only", "ул. Ленинская, 5", [2]float64{}, ErrNotFound},
+		{"house is not a prefix", "ул. Ленина, 55", [2]float64{37.1, 55.1}, nil},
+		{"unknown", "ул. Гагарина, 1", [2]float64{}, ErrNotFound},
+		{"empty", "", [2]float64{}, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			point, err := g.Geocode(tt.address)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Geocode(%q) error = %v, want %v", tt.address, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Geocode(%q) error = %v", tt.address, err)
+			}
+			if point.Lng() != tt.want[0] || point.Lat() != tt.want[1] {
+				t.Errorf("Geocode(%q) = [%v, %v], want %v", tt.address, point.Lng(), point.Lat(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      *models.Point
+		want      float64 // meters
+		tolerance float64
+	}{
+		{"same point", models.NewPoint(55.75, 37.61), models.NewPoint(55.75, 37.61), 0, 1e-6},
+		{"degree of latitude", models.NewPoint(0, 0), models.NewPoint(1, 0), 111194.93, 0.01},
+		{"quarter of equator", models.NewPoint(0, 0), models.NewPoint(0, 90), math.Pi * earthRadius / 2, 0.01},
+		{"antipodes", models.NewPoint(0, 0), models.NewPoint(0, 180), math.Pi * earthRadius, 0.01},
+		{"across the antimeridian", models.NewPoint(0, 179.5), models.NewPoint(0, -179.5), 111194.93, 0.01},
+		{"Moscow to Saint Petersburg", models.NewPoint(55.7558, 37.6173), models.NewPoint(59.9343, 30.3351), 633020, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Distance(tt.a, tt.b)
+			if math.Abs(got-tt.want) > tt.tolerance {
+				t.Errorf("Distance = %.2f, want %.2f", got, tt.want)
+			}
+			if back := Distance(tt.b, tt.a); math.Abs(back-got) > 1e-6 {
+				t.Errorf("Distance is not symmetric: %.6f and %.6f", got, back)
+			}
+		})
+	}
+}
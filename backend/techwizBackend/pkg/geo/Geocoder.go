@@ -0,0 +1,62 @@
+package geo
+
+import (
+	"errors"
+	"log"
+	"math"
+	"techwizBackend/pkg/config"
+	"techwizBackend/pkg/models"
+)
+
+var (
+	ErrNotFound = errors.New("Address not found")
+	ErrDisabled = errors.New("Geocoding is disabled")
+)
+
+// Geocoder converts a free text address to coordinates
+type Geocoder interface {
+	Geocode(address string) (*models.Point, error)
+}
+
+// New selects the geocoder by the config, an unknown or failed one is replaced by None
+func New(cfg *config.Config) Geocoder {
+	switch cfg.Geocoder {
+	case "gazetteer":
+		gazetteer, err := LoadGazetteer(cfg.GazetteerFile)
+		if err != nil {
+			log.Printf("geocoder: %s, geocoding is disabled", err)
+			return None{}
+		}
+		return gazetteer
+	case "nominatim":
+		return NewNominatim(cfg.NominatimURL)
+	case "none", "":
+		return None{}
+	default:
+		log.Printf("geocoder: unknown %q, geocoding is disabled", cfg.Geocoder)
+		return None{}
+	}
+}
+
+// None is used when geocoding is disabled, coordinates are set by hand
+type None struct{}
+
+func (None) Geocode(string) (*models.Point, error) {
+	return nil, ErrDisabled
+}
+
+const earthRadius = 6371000 // meters
+
+// Distance returns the great-circle distance between the points in meters
+func Distance(a *models.Point, b *models.Point) float64 {
+	lat1, lat2 := radians(a.Lat()), radians(b.Lat())
+	dLat := lat2 - lat1
+	dLng := radians(b.Lng() - a.Lng())
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
@@ -0,0 +1,62 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"techwizBackend/pkg/models"
+	"time"
+)
+
+// Nominatim geocodes addresses with the OpenStreetMap search API
+type Nominatim struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewNominatim(baseURL string) *Nominatim {
+	return &Nominatim{
+		baseURL: baseURL,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (n Nominatim) Geocode(address string) (*models.Point, error) {
+	query := url.Values{"q": {address}, "format": {"json"}, "limit": {"1"}}
+	req, err := http.NewRequest(http.MethodGet, n.baseURL+"/search?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	// the usage policy requires an identifying user agent
+	req.Header.Set("User-Agent", "TechPower backend")
+
+	res, err := n.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("geocoding failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("geocoding failed: status %d", res.StatusCode)
+	}
+
+	var places []struct {
+		Lat string `json:"lat"`
+		Lon string `json:"lon"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&places); err != nil {
+		return nil, fmt.Errorf("geocoding failed: %w", err)
+	}
+	if len(places) == 0 {
+		return nil, ErrNotFound
+	}
+
+	lat, err1 := strconv.ParseFloat(places[0].Lat, 64)
+	lng, err2 := strconv.ParseFloat(places[0].Lon, 64)
+	if err1 != nil || err2 != nil {
+		return nil, fmt.Errorf("geocoding failed: invalid coordinates")
+	}
+	return models.NewPoint(lat, lng), nil
+}
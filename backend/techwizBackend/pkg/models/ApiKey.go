@@ -0,0 +1,35 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"time"
+)
+
+// Scopes of partner keys
+const (
+	ScopeRequestCreate string = "request:create"
+	ScopeRequestRead   string = "request:read" // only requests created with the key
+)
+
+// ApiKey is issued by the admin to a lead source, only the hash of the key is stored
+type ApiKey struct {
+	Id         bson.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Name       string        `json:"name" bson:"name"`
+	Prefix     string        `json:"prefix" bson:"prefix"` // first characters to recognise the key
+	Hash       string        `json:"-" bson:"hash"`
+	Key        string        `json:"key,omitempty" bson:"-"` // shown once on creation
+	Scopes     []string      `json:"scopes" bson:"scopes"`
+	RateLimit  int           `json:"rate_limit,omitempty" bson:"rate_limit,omitempty"` // requests per minute, 0 - default
+	Active     bool          `json:"active" bson:"active"`
+	CreatedAt  time.Time     `json:"created_at" bson:"created_at"`
+	LastUsedAt *time.Time    `json:"last_used_at,omitempty" bson:"last_used_at,omitempty"`
+}
+
+func (k ApiKey) HasScope(scope string) bool {
+	for _, item := range k.Scopes {
+		if item == scope {
+			return true
+		}
+	}
+	return false
+}
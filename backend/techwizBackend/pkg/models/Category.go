@@ -3,6 +3,7 @@ package models
 import "go.mongodb.org/mongo-driver/v2/bson"
 
 type Category struct {
-	Id   *bson.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	Name string         `json:"name,omitempty" bson:"name,omitempty"`
+	Id       *bson.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Name     string         `json:"name,omitempty" bson:"name,omitempty"`
+	Duration int            `json:"duration,omitempty" bson:"duration,omitempty"` // estimated job duration in minutes
 }
@@ -1,12 +1,37 @@
 package models
 
-import "go.mongodb.org/mongo-driver/v2/bson"
+import (
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"time"
+)
 
 // first member is always the owner, unless the chat is a group
 type Chat struct {
-	Id         *bson.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
-	Name       string          `json:"name,omitempty" bson:"name,omitempty"`
-	MembersId  []bson.ObjectID `json:"members_id,omitempty" bson:"members_id,omitempty"`
-	Category   *Category       `json:"category,omitempty" bson:"category,omitempty"`
-	CategoryId *bson.ObjectID  `json:"category_id,omitempty" bson:"category_id,omitempty"`
+	Id         *bson.ObjectID        `json:"id,omitempty" bson:"_id,omitempty"`
+	Name       string                `json:"name,omitempty" bson:"name,omitempty"`
+	MembersId  []bson.ObjectID       `json:"members_id,omitempty" bson:"members_id,omitempty"`
+	Category   *Category             `json:"category,omitempty" bson:"category,omitempty"`
+	CategoryId *bson.ObjectID        `json:"category_id,omitempty" bson:"category_id,omitempty"`
+	ReadBy     map[string]ReadMarker `json:"read_by,omitempty" bson:"read_by,omitempty"` // member id hex -> last read message, shown for personal chats only
+	Unread     int64                 `json:"unread" bson:"-"`                            // for the member who asked
+}
+
+// IsGroup is a category chat or a chat of many members, they show counts instead of receipts
+func (c Chat) IsGroup() bool {
+	return c.CategoryId != nil || len(c.MembersId) > 2
+}
+
+// ReadMarker is the last message read by the member
+type ReadMarker struct {
+	MessageId bson.ObjectID `json:"message_id" bson:"message_id"`
+	At        time.Time     `json:"at" bson:"at"`
+}
+
+// ReadReceipt is sent to the other members, group chats get ReadCount instead of UserId
+type ReadReceipt struct {
+	ChatId    bson.ObjectID  `json:"chat_id"`
+	UserId    *bson.ObjectID `json:"user_id,omitempty"`
+	MessageId bson.ObjectID  `json:"message_id"`
+	At        time.Time      `json:"at"`
+	ReadCount *int           `json:"read_count,omitempty"` // members who read up to the message
 }
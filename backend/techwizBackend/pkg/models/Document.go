@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// DocumentKind can be invoice or act
+const (
+	Invoice string = "invoice"
+	Act     string = "act"
+)
+
+// Document is a generated PDF that is stored with the request
+type Document struct {
+	Id        bson.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	RequestId bson.ObjectID `json:"request_id" bson:"request_id"`
+	Kind      string        `json:"kind" bson:"kind"`
+	FileName  string        `json:"file_name" bson:"file_name"`
+	Content   []byte        `json:"-" bson:"content"`
+	CreatedAt time.Time     `json:"created_at" bson:"created_at"`
+}
+
+// DocumentTemplate is the company settings of printable documents.
+// Titles and footer are text/template strings with the fields of DocumentData
+type DocumentTemplate struct {
+	CompanyName    string `json:"company_name" bson:"company_name"`
+	CompanyAddress string `json:"company_address" bson:"company_address"`
+	CompanyPhone   string `json:"company_phone" bson:"company_phone"`
+	CompanyDetails string `json:"company_details" bson:"company_details"` // INN, bank account, etc.
+	InvoiceTitle   string `json:"invoice_title" bson:"invoice_title"`
+	ActTitle       string `json:"act_title" bson:"act_title"`
+	Footer         string `json:"footer" bson:"footer"`
+}
+
+// DocumentData is passed to the templates of DocumentTemplate.
+// The templates are edited by the admin, so only printable fields are here
+type DocumentData struct {
+	Number   string
+	Date     string
+	Customer string
+	Phone    string
+	Address  string
+	Problem  string
+	Category string
+	Master   string
+	Items    []LineItem
+	Total    float64
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ProtocolVersion of the websocket envelope, clients without "v" are treated as the current version
+const ProtocolVersion = 1
+
+// Types of websocket events
+const (
+	WsMessageSend    string = "message.send"   // client -> server
+	WsMessageEdit    string = "message.edit"   // client -> server, WsMessageChange
+	WsMessageDelete  string = "message.delete" // client -> server, WsMessageChange without text
+	WsSync           string = "sync"           // client -> server, missed messages are sent as message.new
+	WsRead           string = "read"           // both directions, ReadReceipt to the other members
+	WsMessageNew     string = "message.new"
+	WsMessageEdited  string = "message.edited"  // the changed message to the chat members
+	WsMessageDeleted string = "message.deleted" // the tombstone to the chat members
+	WsAck            string = "ack"
+	WsError          string = "error"    // nack of the client id or a server error
+	WsTyping         string = "typing"   // both directions, Typing
+	WsPresence       string = "presence" // Presence of the users who share a chat
+	WsNotification   string = "notification"
+)
+
+// Error codes of the "error" event
+const (
+	WsErrBadEnvelope        string = "bad_envelope"
+	WsErrUnsupportedVersion string = "unsupported_version"
+	WsErrUnknownType        string = "unknown_type"
+	WsErrBadPayload         string = "bad_payload"
+	WsErrSaveFailed         string = "save_failed"
+	WsErrSyncFailed         string = "sync_failed"
+	WsErrReadFailed         string = "read_failed"
+	WsErrForbidden          string = "forbidden"
+	WsErrNotFound           string = "not_found"
+	WsErrConflict           string = "conflict"
+)
+
+// Envelope is every frame of the websocket in both directions
+type Envelope struct {
+	Version int             `json:"v"`
+	Type    string          `json:"type"`
+	Id      string          `json:"id,omitempty"` // set by the client, repeated in ack and error
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// WsErrorPayload is the payload of the "error" event
+type WsErrorPayload struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// WsSyncPayload is the last message the client has seen, without both fields the delivery cursor of the user is used
+type WsSyncPayload struct {
+	AfterId *bson.ObjectID `json:"after_id,omitempty"`
+	After   *time.Time     `json:"after,omitempty"`
+}
+
+// WsSyncResult is the payload of the ack of "sync", with More the client repeats sync from LastId
+type WsSyncResult struct {
+	Count  int            `json:"count"`
+	LastId *bson.ObjectID `json:"last_id,omitempty"`
+	More   bool           `json:"more"`
+}
+
+// WsMessageChange edits or deletes the message
+type WsMessageChange struct {
+	Id   bson.ObjectID `json:"id"`
+	Text string        `json:"text,omitempty"`
+}
+
+// WsReadPayload marks the chat read up to the message, without MessageId up to the last one
+type WsReadPayload struct {
+	ChatId    bson.ObjectID  `json:"chat_id"`
+	MessageId *bson.ObjectID `json:"message_id,omitempty"`
+}
+
+func NewEnvelope(eventType string, id string, payload any) (Envelope, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Envelope{}, err
+	}
+	return Envelope{Version: ProtocolVersion, Type: eventType, Id: id, Payload: data}, nil
+}
+
+func NewErrorEnvelope(id string, code string, message string) Envelope {
+	envelope, _ := NewEnvelope(WsError, id, WsErrorPayload{Code: code, Message: message})
+	return envelope
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"time"
+)
+
+// Job status can be pending, running, done, failed or canceled
+const (
+	JobPending  string = "pending"
+	JobRunning  string = "running"
+	JobDone     string = "done"
+	JobFailed   string = "failed"
+	JobCanceled string = "canceled"
+)
+
+// Job is a delayed or recurring task, Type selects the registered handler.
+// A running job is leased by one instance until LockedUntil
+type Job struct {
+	Id          bson.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	Type        string         `json:"type" bson:"type"`
+	Key         string         `json:"key,omitempty" bson:"key,omitempty"` // a single pending job per key
+	Payload     map[string]any `json:"payload,omitempty" bson:"payload,omitempty"`
+	Cron        string         `json:"cron,omitempty" bson:"cron,omitempty"` // recurring jobs only
+	Status      string         `json:"status" bson:"status"`
+	RunAt       time.Time      `json:"run_at" bson:"run_at"`
+	Attempts    int            `json:"attempts" bson:"attempts"`
+	MaxAttempts int            `json:"max_attempts" bson:"max_attempts"`
+	LastError   string         `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	LockedBy    string         `json:"locked_by,omitempty" bson:"locked_by,omitempty"`
+	LockedUntil *time.Time     `json:"locked_until,omitempty" bson:"locked_until,omitempty"`
+	CreatedAt   time.Time      `json:"created_at" bson:"created_at"`
+	FinishedAt  *time.Time     `json:"finished_at,omitempty" bson:"finished_at,omitempty"`
+}
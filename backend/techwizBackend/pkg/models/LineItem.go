@@ -0,0 +1,28 @@
+package models
+
+// LineType can be labour or parts
+const (
+	LineLabour string = "labour"
+	LineParts  string = "parts"
+)
+
+type LineItem struct {
+	Type        string  `json:"type,omitempty" bson:"type,omitempty"`
+	Description string  `json:"description,omitempty" bson:"description,omitempty"`
+	Quantity    float64 `json:"quantity,omitempty" bson:"quantity,omitempty"`
+	UnitPrice   float64 `json:"unit_price,omitempty" bson:"unit_price,omitempty"`
+	Commission  float64 `json:"commission,omitempty" bson:"commission,omitempty"` // set on completion
+}
+
+func (i LineItem) Total() float64 {
+	return i.Quantity * i.UnitPrice
+}
+
+func (i LineItem) IsValid() bool {
+	switch i.Type {
+	case LineLabour, LineParts:
+	default:
+		return false
+	}
+	return i.Description != "" && i.Quantity > 0 && i.UnitPrice >= 0
+}
@@ -3,17 +3,39 @@ package models
 import (
 	"time"
 
-	"github.com/gorilla/websocket"
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
 type Message struct {
 	Id             bson.ObjectID   `json:"id,omitempty" bson:"_id,omitempty"`
-	Conn           *websocket.Conn `json:"-" bson:"-"`
 	SenderId       bson.ObjectID   `json:"sender_id,omitempty" bson:"sender_id"`
 	SenderFullName string          `json:"sender_full_name,omitempty" bson:"sender_full_name"`
 	RecipientId    *bson.ObjectID  `json:"recipient_id" bson:"recipient_id"`
 	Chat           bson.ObjectID   `json:"chat_id,omitempty" bson:"chat_id"`
 	Text           string          `json:"text,omitempty" bson:"text,omitempty"`
 	CreatedAt      time.Time       `json:"created_at,omitempty" bson:"created_at,omitempty"` // format time.RFC3339
+	DeliveredTo    []bson.ObjectID `json:"-" bson:"delivered_to,omitempty"`                  // users whose connection got the message, used only by sync
+	EditedAt       *time.Time      `json:"edited_at,omitempty" bson:"edited_at,omitempty"`
+	DeletedAt      *time.Time      `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"` // tombstone, the text is removed
+	DeletedBy      *bson.ObjectID  `json:"deleted_by,omitempty" bson:"deleted_by,omitempty"`
+	Edits          []MessageEdit   `json:"-" bson:"edits,omitempty"` // previous versions for audit
+}
+
+// MessageEdit is a replaced version of the text
+type MessageEdit struct {
+	Text       string        `json:"text" bson:"text"`
+	ReplacedAt time.Time     `json:"replaced_at" bson:"replaced_at"`
+	ReplacedBy bson.ObjectID `json:"replaced_by" bson:"replaced_by"`
+}
+
+// MessageAnchor is a position in the chat history, by the message id or by the time
+type MessageAnchor struct {
+	Id *bson.ObjectID
+	At *time.Time
+}
+
+// MessagePage is a page of the chat history, the newest message first
+type MessagePage struct {
+	Messages []Message `json:"messages"`
+	HasMore  bool      `json:"has_more"` // there are messages further in the direction of the page
 }
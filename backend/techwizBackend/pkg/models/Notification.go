@@ -0,0 +1,27 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"time"
+)
+
+// Notification kinds
+const (
+	NotifyRequestAttached string = "request_attached"
+	NotifyRequestStatus   string = "request_status"
+	NotifyBalanceCredited string = "balance_credited"
+	NotifyCategoryAdded   string = "category_added"
+	NotifySlaBreach       string = "sla_breach"
+)
+
+type Notification struct {
+	Id        bson.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	UserId    bson.ObjectID  `json:"user_id" bson:"user_id"`
+	Kind      string         `json:"kind" bson:"kind"`
+	Text      string         `json:"text" bson:"text"`
+	RequestId *bson.ObjectID `json:"request_id,omitempty" bson:"request_id,omitempty"`
+	Data      map[string]any `json:"data,omitempty" bson:"data,omitempty"`
+	Read      bool           `json:"read" bson:"read"`
+	CreatedAt time.Time      `json:"created_at" bson:"created_at"`
+	ReadAt    *time.Time     `json:"read_at,omitempty" bson:"read_at,omitempty"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"time"
+)
+
+// PartnerRequest is the request as it leaves the company: webhooks and the partner api.
+// Internal fields (commission, tracking token, SLA) are not here, the master is only named
+type PartnerRequest struct {
+	Id          bson.ObjectID  `json:"id"`
+	FullName    string         `json:"full_name,omitempty"`
+	PhoneNumber string         `json:"phone_number,omitempty"`
+	Address     string         `json:"address,omitempty"`
+	Location    *Point         `json:"location,omitempty"`
+	Problem     string         `json:"problem,omitempty"`
+	Price       float64        `json:"price,omitempty"`
+	Items       []LineItem     `json:"items,omitempty"`
+	Status      Status         `json:"status"`
+	StatusAt    time.Time      `json:"status_at"`
+	DateTime    time.Time      `json:"datetime,omitempty"`
+	Category    string         `json:"category,omitempty"`
+	Worker      *PartnerWorker `json:"worker,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	SourceId    *bson.ObjectID `json:"source_id,omitempty"`
+}
+
+type PartnerWorker struct {
+	Id       bson.ObjectID `json:"id"`
+	FullName string        `json:"full_name,omitempty"`
+}
+
+func NewPartnerRequest(r *Request) PartnerRequest {
+	res := PartnerRequest{
+		Id:          r.Id,
+		FullName:    r.FullName,
+		PhoneNumber: r.PhoneNumber,
+		Address:     r.Address,
+		Location:    r.Location,
+		Problem:     r.Problem,
+		Price:       r.Price,
+		Status:      r.Status,
+		StatusAt:    r.StatusAt,
+		DateTime:    r.DateTime,
+		CreatedAt:   r.CreatedAt,
+		SourceId:    r.SourceId,
+	}
+	for _, item := range r.Items {
+		item.Commission = 0
+		res.Items = append(res.Items, item)
+	}
+	if r.Category != nil {
+		res.Category = r.Category.Name
+	}
+	if r.Worker != nil {
+		res.Worker = &PartnerWorker{Id: r.Worker.Id, FullName: r.Worker.FullName}
+	}
+	return res
+}
+
+// PartnerRequestInput is everything a partner can set on a new request,
+// the fields have the same names as in Request
+type PartnerRequestInput struct {
+	FullName    string         `json:"full_name"`
+	PhoneNumber string         `json:"phone_number"`
+	Address     string         `json:"address"`
+	CategoryId  *bson.ObjectID `json:"category_id,omitempty"`
+	DateTime    time.Time      `json:"datetime"`
+	Problem     string         `json:"problem"`
+}
+
+func (i PartnerRequestInput) Request() Request {
+	return Request{
+		FullName:    i.FullName,
+		PhoneNumber: i.PhoneNumber,
+		Address:     i.Address,
+		CategoryId:  i.CategoryId,
+		DateTime:    i.DateTime,
+		Problem:     i.Problem,
+	}
+}
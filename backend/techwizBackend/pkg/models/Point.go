@@ -0,0 +1,32 @@
+package models
+
+// Point is a GeoJSON point, coordinates are [longitude, latitude]
+type Point struct {
+	Type        string    `json:"type" bson:"type"`
+	Coordinates []float64 `json:"coordinates" bson:"coordinates"`
+}
+
+func NewPoint(lat float64, lng float64) *Point {
+	return &Point{Type: "Point", Coordinates: []float64{lng, lat}}
+}
+
+func (p Point) Lat() float64 {
+	return p.Coordinates[1]
+}
+
+func (p Point) Lng() float64 {
+	return p.Coordinates[0]
+}
+
+func (p Point) IsValid() bool {
+	if p.Type != "Point" || len(p.Coordinates) != 2 {
+		return false
+	}
+	return p.Lat() >= -90 && p.Lat() <= 90 && p.Lng() >= -180 && p.Lng() <= 180
+}
+
+// NearbyMaster is a master with the distance to the request in meters
+type NearbyMaster struct {
+	User     `bson:",inline"`
+	Distance float64 `json:"distance" bson:"distance"`
+}
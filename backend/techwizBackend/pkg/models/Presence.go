@@ -0,0 +1,20 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"time"
+)
+
+// Presence is the online status of the user, LastSeen is set when the user is offline
+type Presence struct {
+	UserId   bson.ObjectID `json:"user_id"`
+	Online   bool          `json:"online"`
+	LastSeen *time.Time    `json:"last_seen,omitempty"`
+}
+
+// Typing is sent to the other members of the chat
+type Typing struct {
+	ChatId bson.ObjectID `json:"chat_id"`
+	UserId bson.ObjectID `json:"user_id,omitempty"` // set by the server
+	Typing bool          `json:"typing"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// QuoteStatus can be pending, approved, rejected or superseded
+// pending = waiting for the dispatcher or the customer
+// approved = request returns to in work at the new price
+// rejected = request is canceled and the bail is applied
+// superseded = replaced by a newer revision or by a status change of the request
+const (
+	QuotePending    string = "pending"
+	QuoteApproved   string = "approved"
+	QuoteRejected   string = "rejected"
+	QuoteSuperseded string = "superseded"
+)
+
+// DecidedVia shows who approved or rejected the quote
+const (
+	QuoteByDispatcher string = "dispatcher"
+	QuoteByCustomer   string = "customer"
+)
+
+// Quote is one revision of the price upgrade proposed by the master (status 5)
+type Quote struct {
+	Id         bson.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	RequestId  bson.ObjectID  `json:"request_id" bson:"request_id"`
+	Revision   int            `json:"revision" bson:"revision"`
+	Items      []LineItem     `json:"items,omitempty" bson:"items,omitempty"`
+	Total      float64        `json:"total" bson:"total"`
+	Bail       float64        `json:"bail" bson:"bail"` // charged if the quote is rejected
+	Reason     string         `json:"reason,omitempty" bson:"reason,omitempty"`
+	Status     string         `json:"status,omitempty" bson:"status,omitempty"`
+	ProposedBy bson.ObjectID  `json:"proposed_by" bson:"proposed_by"`
+	DecidedBy  *bson.ObjectID `json:"decided_by,omitempty" bson:"decided_by,omitempty"` // empty if decided by customer
+	DecidedVia string         `json:"decided_via,omitempty" bson:"decided_via,omitempty"`
+	Comment    string         `json:"comment,omitempty" bson:"comment,omitempty"`
+	CreatedAt  time.Time      `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	DecidedAt  *time.Time     `json:"decided_at,omitempty" bson:"decided_at,omitempty"`
+}
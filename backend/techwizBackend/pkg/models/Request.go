@@ -21,21 +21,71 @@ type Status struct {
 	//FilesDAO    []FileDAO `json:"-" bson:"files,omitempty"`
 }
 
+// Name is the status as the dispatcher sees it
+func (s Status) Name() string {
+	switch s.Code {
+	case 1:
+		return "Ожидает назначения"
+	case 2:
+		return "Назначен мастеру"
+	case 3:
+		return "В работе"
+	case 4:
+		return "Выполнен"
+	case 5:
+		return "Модернизация"
+	case 6:
+		return "Отменен"
+	case 7:
+		return "Отклонен"
+	default:
+		return "Неизвестен"
+	}
+}
+
 type Request struct {
-	Id          bson.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
-	FullName    string         `json:"full_name,omitempty" bson:"full_name,omitempty"`
-	PhoneNumber string         `json:"phone_number,omitempty" bson:"phone_number,omitempty"`
-	Address     string         `json:"address,omitempty" bson:"address,omitempty"`
-	Problem     string         `json:"problem,omitempty" bson:"problem,omitempty"`
-	Price       float64        `json:"price,omitempty" bson:"price,omitempty"`
-	Status      Status         `json:"status,omitempty" bson:"status,omitempty"`
-	InSpot      bool           `json:"in_spot,omitempty" bson:"in_spot,omitempty"`
-	Premium     bool           `json:"premium,omitempty" bson:"premium,omitempty"`
-	DateTime    time.Time      `json:"datetime,omitempty" bson:"datetime,omitempty"`
-	Category    *Category      `json:"category,omitempty" bson:"category,omitempty"`
-	CategoryId  *bson.ObjectID `json:"category_id,omitempty" bson:"category_id,omitempty"`
-	Commission  float64        `json:"commission,omitempty" bson:"commission,omitempty"`
-	Worker      *User          `json:"worker,omitempty" bson:"worker,omitempty"`
-	WorkerId    *bson.ObjectID `json:"worker_id,omitempty" bson:"worker_id,omitempty"`
-	CreatedAt   time.Time      `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	Id            bson.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	FullName      string         `json:"full_name,omitempty" bson:"full_name,omitempty"`
+	PhoneNumber   string         `json:"phone_number,omitempty" bson:"phone_number,omitempty"`
+	Address       string         `json:"address,omitempty" bson:"address,omitempty"`
+	Location      *Point         `json:"location,omitempty" bson:"location,omitempty"` // geocoded address or set by hand
+	Problem       string         `json:"problem,omitempty" bson:"problem,omitempty"`
+	Price         float64        `json:"price,omitempty" bson:"price,omitempty"` // total of items if they are set
+	Items         []LineItem     `json:"items,omitempty" bson:"items,omitempty"`
+	Status        Status         `json:"status,omitempty" bson:"status,omitempty"`
+	StatusAt      time.Time      `json:"status_at,omitempty" bson:"status_at,omitempty"` // time of the last status change
+	Sla           *SlaBreach     `json:"sla,omitempty" bson:"sla,omitempty"`
+	InSpot        bool           `json:"in_spot,omitempty" bson:"in_spot,omitempty"`
+	Visit         *Visit         `json:"visit,omitempty" bson:"visit,omitempty"`
+	Premium       bool           `json:"premium,omitempty" bson:"premium,omitempty"`
+	DateTime      time.Time      `json:"datetime,omitempty" bson:"datetime,omitempty"`
+	Category      *Category      `json:"category,omitempty" bson:"category,omitempty"`
+	CategoryId    *bson.ObjectID `json:"category_id,omitempty" bson:"category_id,omitempty"`
+	Commission    float64        `json:"commission,omitempty" bson:"commission,omitempty"`
+	Worker        *User          `json:"worker,omitempty" bson:"worker,omitempty"`
+	WorkerId      *bson.ObjectID `json:"worker_id,omitempty" bson:"worker_id,omitempty"`
+	CreatedAt     time.Time      `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	TrackingToken string         `json:"tracking_token,omitempty" bson:"tracking_token,omitempty"` // public token for the customer
+	SourceId      *bson.ObjectID `json:"source_id,omitempty" bson:"source_id,omitempty"`           // partner api key, empty for our own UI
+	Warnings      []string       `json:"warnings,omitempty" bson:"-"`                              // not saved, e.g. schedule conflicts
+}
+
+func (r Request) ItemsTotal() float64 {
+	var total float64
+	for _, item := range r.Items {
+		total += item.Total()
+	}
+	return total
+}
+
+// CommissionTotal is the company share of the completed request
+func (r Request) CommissionTotal() float64 {
+	if len(r.Items) == 0 {
+		return r.Price * r.Commission
+	}
+	var total float64
+	for _, item := range r.Items {
+		total += item.Total() * item.Commission
+	}
+	return total
 }
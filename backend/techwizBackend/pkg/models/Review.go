@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Source shows who left the review
+const (
+	ReviewByDispatcher string = "dispatcher"
+	ReviewByCustomer   string = "customer"
+)
+
+// Review is the rating of the master for a completed request, one per request
+type Review struct {
+	Id        bson.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	RequestId bson.ObjectID  `json:"request_id" bson:"request_id"`
+	MasterId  bson.ObjectID  `json:"master_id" bson:"master_id"`
+	Rating    int            `json:"rating" bson:"rating"` // 1-5
+	Comment   string         `json:"comment,omitempty" bson:"comment,omitempty"`
+	Source    string         `json:"source" bson:"source"`                           // customer or dispatcher
+	AuthorId  *bson.ObjectID `json:"author_id,omitempty" bson:"author_id,omitempty"` // empty if left by customer
+	CreatedAt time.Time      `json:"created_at" bson:"created_at"`
+}
+
+func (r Review) IsValid() bool {
+	return r.Rating >= 1 && r.Rating <= 5
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"time"
+)
+
+// Route is the suggested order of visits of the master for a day
+type Route struct {
+	Id            bson.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	MasterId      bson.ObjectID `json:"master_id" bson:"master_id"`
+	Date          string        `json:"date" bson:"date"`                       // format 2006-01-02 in the company timezone
+	Start         *Point        `json:"start,omitempty" bson:"start,omitempty"` // base of the master
+	Stops         []RouteStop   `json:"stops" bson:"stops"`
+	Distance      float64       `json:"distance" bson:"distance"` // meters
+	TravelMinutes int           `json:"travel_minutes" bson:"travel_minutes"`
+	Warnings      []string      `json:"warnings,omitempty" bson:"warnings,omitempty"`
+	UpdatedAt     time.Time     `json:"updated_at" bson:"updated_at"`
+}
+
+// RouteStop is a visit, the master is expected in [DateTime, DateTime + window]
+type RouteStop struct {
+	RequestId     bson.ObjectID `json:"request_id" bson:"request_id"`
+	Address       string        `json:"address,omitempty" bson:"address,omitempty"`
+	Location      *Point        `json:"location,omitempty" bson:"location,omitempty"`
+	DateTime      time.Time     `json:"datetime" bson:"datetime"`
+	Arrival       time.Time     `json:"arrival" bson:"arrival"`
+	Departure     time.Time     `json:"departure" bson:"departure"`
+	Distance      float64       `json:"distance" bson:"distance"` // meters from the previous stop
+	TravelMinutes int           `json:"travel_minutes" bson:"travel_minutes"`
+	Late          bool          `json:"late,omitempty" bson:"late,omitempty"` // arrival is after the window
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Schedule of the master, hours and days are in the company timezone
+type Schedule struct {
+	WorkingHours []WorkingHours `json:"working_hours,omitempty" bson:"working_hours,omitempty"` // days without hours are days off
+	DaysOff      []string       `json:"days_off,omitempty" bson:"days_off,omitempty"`           // format 2006-01-02
+	Blocked      []TimeSlot     `json:"blocked,omitempty" bson:"blocked,omitempty"`
+}
+
+// Weekday can be 0-6, 0 = sunday
+type WorkingHours struct {
+	Weekday int    `json:"weekday" bson:"weekday"`
+	Start   string `json:"start" bson:"start"` // format 15:04
+	End     string `json:"end" bson:"end"`     // format 15:04
+}
+
+type TimeSlot struct {
+	From   time.Time `json:"from" bson:"from"`
+	To     time.Time `json:"to" bson:"to"`
+	Reason string    `json:"reason,omitempty" bson:"reason,omitempty"`
+}
+
+func (t TimeSlot) Overlaps(from, to time.Time) bool {
+	return t.From.Before(to) && from.Before(t.To)
+}
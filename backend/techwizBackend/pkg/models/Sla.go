@@ -0,0 +1,53 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"time"
+)
+
+// SlaPolicy limits the time a request may stay in the status.
+// Empty CategoryId or Premium matches any request, the most specific policy wins
+type SlaPolicy struct {
+	Id         *bson.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Name       string         `json:"name,omitempty" bson:"name,omitempty"`
+	Status     int            `json:"status" bson:"status"`
+	CategoryId *bson.ObjectID `json:"category_id,omitempty" bson:"category_id,omitempty"`
+	Premium    *bool          `json:"premium,omitempty" bson:"premium,omitempty"`
+	Minutes    int            `json:"minutes" bson:"minutes"`
+}
+
+// Matches checks that the policy applies to the request in its current status
+func (p SlaPolicy) Matches(request *Request) bool {
+	if p.Status != request.Status.Code {
+		return false
+	}
+	if p.CategoryId != nil && (request.CategoryId == nil || *p.CategoryId != *request.CategoryId) {
+		return false
+	}
+	if p.Premium != nil && *p.Premium != request.Premium {
+		return false
+	}
+	return true
+}
+
+// Specificity orders matching policies, category is more specific than the premium flag
+func (p SlaPolicy) Specificity() int {
+	res := 0
+	if p.CategoryId != nil {
+		res += 2
+	}
+	if p.Premium != nil {
+		res++
+	}
+	return res
+}
+
+// SlaBreach flags the request that stayed in the status longer than allowed,
+// it is removed when the status changes
+type SlaBreach struct {
+	Status     int            `json:"status" bson:"status"`
+	Since      time.Time      `json:"since" bson:"since"`
+	Deadline   time.Time      `json:"deadline" bson:"deadline"`
+	BreachedAt time.Time      `json:"breached_at" bson:"breached_at"`
+	PolicyId   *bson.ObjectID `json:"policy_id,omitempty" bson:"policy_id,omitempty"` // empty for the default limit
+}
@@ -2,11 +2,15 @@ package models
 
 // Statistics структура для хранения результата статистики
 type Statistics struct {
-	TotalOrders      int64          `json:"total_orders" bson:"total_orders"`
-	TotalCommissions int64          `json:"total_commissions" bson:"total_commissions"`
-	CompletedOrders  int64          `json:"completed_orders" bson:"completed_orders"`
-	TotalRevenue     float64        `json:"total_revenue" bson:"total_revenue"`
-	ActiveMasters    int64          `json:"active_masters" bson:"active_masters"`
-	OrdersByCity     map[string]int `json:"orders_by_city" bson:"orders_by_city"`
-	OrdersByCategory map[string]int `json:"orders_by_category" bson:"orders_by_category"`
+	TotalOrders      int64              `json:"total_orders" bson:"total_orders"`
+	TotalCommissions float64            `json:"total_commissions" bson:"total_commissions"`
+	CompletedOrders  int64              `json:"completed_orders" bson:"completed_orders"`
+	TotalRevenue     float64            `json:"total_revenue" bson:"total_revenue"`
+	RevenueByLine    map[string]float64 `json:"revenue_by_line_type" bson:"revenue_by_line_type"`
+	ActiveMasters    int64              `json:"active_masters" bson:"active_masters"`
+	AvgOnSite        float64            `json:"avg_on_site_minutes" bson:"avg_on_site_minutes"`
+	TotalOnSite      int64              `json:"total_on_site_minutes" bson:"total_on_site_minutes"`
+	OrdersByCity     map[string]int     `json:"orders_by_city" bson:"orders_by_city"`
+	OrdersByCategory map[string]int     `json:"orders_by_category" bson:"orders_by_category"`
+	OrdersBySource   map[string]int     `json:"orders_by_source" bson:"orders_by_source"` // name of the api key, "direct" for our own UI
 }
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// TierDecisionStatus can be proposed, applied, rejected or superseded
+// proposed = waiting for the admin
+// applied = tier of the master is changed
+// rejected = admin declined the proposal
+// superseded = replaced by a newer evaluation
+const (
+	TierProposed   string = "proposed"
+	TierApplied    string = "applied"
+	TierRejected   string = "rejected"
+	TierSuperseded string = "superseded"
+)
+
+// Source of the decision
+const (
+	TierByEvaluation string = "evaluation"
+	TierByAdmin      string = "admin"
+)
+
+type TierMetrics struct {
+	Total       int64   `json:"total" bson:"total"` // requests attached to the master
+	Completed   int64   `json:"completed" bson:"completed"`
+	Canceled    int64   `json:"canceled" bson:"canceled"`
+	CancelRate  float64 `json:"cancel_rate" bson:"cancel_rate"`
+	Rating      float64 `json:"rating" bson:"rating"`
+	RatingCount int     `json:"rating_count" bson:"rating_count"`
+	TenureDays  int     `json:"tenure_days" bson:"tenure_days"`
+}
+
+// TierDecision is a recorded change of the master status (default, senior or premium)
+type TierDecision struct {
+	Id        bson.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	MasterId  bson.ObjectID  `json:"master_id" bson:"master_id"`
+	From      string         `json:"from" bson:"from"`
+	To        string         `json:"to" bson:"to"`
+	Reasons   []string       `json:"reasons,omitempty" bson:"reasons,omitempty"`
+	Metrics   *TierMetrics   `json:"metrics,omitempty" bson:"metrics,omitempty"`
+	Status    string         `json:"status" bson:"status"`
+	Source    string         `json:"source" bson:"source"`
+	DecidedBy *bson.ObjectID `json:"decided_by,omitempty" bson:"decided_by,omitempty"`
+	CreatedAt time.Time      `json:"created_at" bson:"created_at"`
+	DecidedAt *time.Time     `json:"decided_at,omitempty" bson:"decided_at,omitempty"`
+}
@@ -1,13 +1,12 @@
 package models
 
 import (
-	"github.com/gorilla/websocket"
 	"go.mongodb.org/mongo-driver/v2/bson"
+	"time"
 )
 
 type User struct {
 	Id          bson.ObjectID   `json:"id,omitempty" bson:"_id,omitempty"`
-	Conn        *websocket.Conn `json:"-" bson:"-"`
 	PhoneNumber string          `json:"phone_number" bson:"phone_number"`
 	FullName    string          `json:"full_name,omitempty" bson:"full_name,omitempty"`
 	Password    string          `json:"password,omitempty" bson:"password"`
@@ -20,8 +19,21 @@ type User struct {
 	Balance     float64         `json:"balance,omitempty" bson:"balance,omitempty"`       // only master
 	Commission  float64         `json:"commission,omitempty" bson:"commission,omitempty"` // only master
 	Dismissed   bool            `json:"dismissed" bson:"dismissed"`
+	Rating      float64         `json:"rating,omitempty" bson:"rating,omitempty"`             // only master // average of reviews
+	RatingCount int             `json:"rating_count,omitempty" bson:"rating_count,omitempty"` // only master
+	TierLocked  bool            `json:"tier_locked,omitempty" bson:"tier_locked,omitempty"`   // only master // set by admin, skipped by evaluation
+	Schedule    *Schedule       `json:"schedule,omitempty" bson:"schedule,omitempty"`         // only master
+	Base        *Point          `json:"base,omitempty" bson:"base,omitempty"`                 // only master // home base
+	LastSeen    *time.Time      `json:"last_seen,omitempty" bson:"last_seen,omitempty"`       // last websocket disconnect
 }
 
+// Permission can be 100, 010 or 001
+const (
+	Admin      string = "100"
+	Dispatcher string = "010"
+	Master     string = "001"
+)
+
 const (
 	Default string = "default"
 	Senior  string = "senior"
@@ -36,3 +48,7 @@ func (s User) IsValid() bool {
 		return false
 	}
 }
+
+func (s User) IsDispatcher() bool {
+	return s.Permission == Admin || s.Permission == Dispatcher
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"time"
+)
+
+// Visit is the time the master spent at the customer
+type Visit struct {
+	CheckIn       *VisitEvent `json:"check_in,omitempty" bson:"check_in,omitempty"`
+	CheckOut      *VisitEvent `json:"check_out,omitempty" bson:"check_out,omitempty"`
+	OnSiteMinutes int         `json:"on_site_minutes,omitempty" bson:"on_site_minutes,omitempty"` // set by check-out
+}
+
+type VisitEvent struct {
+	At       time.Time     `json:"at" bson:"at"`
+	MasterId bson.ObjectID `json:"master_id" bson:"master_id"`
+	Location *Point        `json:"location,omitempty" bson:"location,omitempty"`
+	Distance *float64      `json:"distance,omitempty" bson:"distance,omitempty"` // meters to the request location
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"time"
+)
+
+// Webhook events
+const (
+	EventRequestCreated  string = "request.created"
+	EventRequestAttached string = "request.attached"
+	EventRequestStatus   string = "request.status_changed"
+	EventRequestDone     string = "request.completed"
+)
+
+// Delivery status can be pending, delivered or dead
+const (
+	DeliveryPending   string = "pending"
+	DeliveryDelivered string = "delivered"
+	DeliveryDead      string = "dead"
+)
+
+// Webhook is an endpoint of a partner, empty Events subscribes to all of them
+type Webhook struct {
+	Id        bson.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Url       string        `json:"url" bson:"url"`
+	Secret    string        `json:"secret,omitempty" bson:"secret"` // key of the HMAC signature
+	Events    []string      `json:"events,omitempty" bson:"events,omitempty"`
+	Active    bool          `json:"active" bson:"active"`
+	CreatedAt time.Time     `json:"created_at" bson:"created_at"`
+}
+
+func (w Webhook) Accepts(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, item := range w.Events {
+		if item == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is a single event sent to a webhook with the log of attempts
+type WebhookDelivery struct {
+	Id          bson.ObjectID     `json:"id,omitempty" bson:"_id,omitempty"`
+	WebhookId   bson.ObjectID     `json:"webhook_id" bson:"webhook_id"`
+	Event       string            `json:"event" bson:"event"`
+	Body        string            `json:"body" bson:"body"` // signed json
+	Status      string            `json:"status" bson:"status"`
+	Attempts    []DeliveryAttempt `json:"attempts,omitempty" bson:"attempts,omitempty"`
+	CreatedAt   time.Time         `json:"created_at" bson:"created_at"`
+	DeliveredAt *time.Time        `json:"delivered_at,omitempty" bson:"delivered_at,omitempty"`
+}
+
+type DeliveryAttempt struct {
+	At         time.Time `json:"at" bson:"at"`
+	StatusCode int       `json:"status_code,omitempty" bson:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty" bson:"error,omitempty"`
+	Duration   int64     `json:"duration_ms" bson:"duration_ms"`
+}
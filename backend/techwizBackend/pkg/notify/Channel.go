@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"log"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Message is addressed by phone to customers and by id to users
+type Message struct {
+	Phone  string         `json:"phone,omitempty"`
+	UserId *bson.ObjectID `json:"user_id,omitempty"`
+	Kind   string         `json:"kind"`
+	Text   string         `json:"text"`
+}
+
+// Channel delivers messages outside the app, e.g. SMS or messengers
+type Channel interface {
+	Send(message Message) error
+}
+
+// New selects the channel by name, only the log channel is built in
+func New(name string) Channel {
+	switch name {
+	case "log", "":
+		return Log{}
+	default:
+		log.Printf("notify: unknown channel %q, messages are logged", name)
+		return Log{}
+	}
+}
+
+// Log writes messages to the log
+type Log struct{}
+
+func (Log) Send(message Message) error {
+	to := message.Phone
+	if message.UserId != nil {
+		to = message.UserId.Hex()
+	}
+	log.Printf("notify %s to %s: %s", message.Kind, to, message.Text)
+	return nil
+}
+
+// Fake records the sent messages, it is used in tests
+type Fake struct {
+	mu   sync.Mutex
+	Sent []Message
+	Err  error // returned by Send if set, the message is not recorded then
+}
+
+func (f *Fake) Send(message Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return f.Err
+	}
+	f.Sent = append(f.Sent, message)
+	return nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"techwizBackend/pkg/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type (
+	IApiKeyRepository interface {
+		Create(key *models.ApiKey) error
+		Get(keys *[]models.ApiKey) error
+		GetByHash(hash string, key *models.ApiKey) error
+		Revoke(id bson.ObjectID) error
+		Touch(id bson.ObjectID) error
+	}
+
+	ApiKeyRepository struct {
+		db *mongo.Client
+	}
+)
+
+func NewApiKeyRepository(db *mongo.Client) *ApiKeyRepository {
+	return &ApiKeyRepository{db: db}
+}
+
+func (r ApiKeyRepository) Create(key *models.ApiKey) error {
+	coll := r.db.Database("TechPower").Collection("ApiKeys")
+	res, err := coll.InsertOne(context.TODO(), key)
+	if err != nil {
+		return errors.New("Failed to create api key")
+	}
+	key.Id = res.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r ApiKeyRepository) Get(keys *[]models.ApiKey) error {
+	coll := r.db.Database("TechPower").Collection("ApiKeys")
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+
+	cursor, err := coll.Find(context.TODO(), bson.M{}, opts)
+	if err != nil {
+		return errors.New("Api keys not found")
+	}
+	defer cursor.Close(context.TODO())
+
+	if err = cursor.All(context.TODO(), keys); err != nil {
+		return errors.New("Failed to get api keys")
+	}
+	return nil
+}
+
+func (r ApiKeyRepository) GetByHash(hash string, key *models.ApiKey) error {
+	coll := r.db.Database("TechPower").Collection("ApiKeys")
+
+	if err := coll.FindOne(context.TODO(), bson.M{"hash": hash}).Decode(key); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errors.New("Api key not found")
+		}
+		return err
+	}
+	return nil
+}
+
+// Revoke deactivates the key, requests keep the reference to it
+func (r ApiKeyRepository) Revoke(id bson.ObjectID) error {
+	coll := r.db.Database("TechPower").Collection("ApiKeys")
+	res, err := coll.UpdateOne(context.TODO(), bson.M{"_id": id}, bson.M{"$set": bson.M{"active": false}})
+	if err != nil {
+		return errors.New("Failed to revoke api key")
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("Api key not found")
+	}
+	return nil
+}
+
+func (r ApiKeyRepository) Touch(id bson.ObjectID) error {
+	coll := r.db.Database("TechPower").Collection("ApiKeys")
+	if _, err := coll.UpdateOne(context.TODO(), bson.M{"_id": id}, bson.M{"$set": bson.M{"last_used_at": time.Now()}}); err != nil {
+		return errors.New("Failed to update api key")
+	}
+	return nil
+}
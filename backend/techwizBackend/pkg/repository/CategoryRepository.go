@@ -16,6 +16,7 @@ type (
 		Rename(*models.Category) error
 		Remove(id bson.ObjectID) error
 		Get(*[]models.Category) error
+		SetDuration(id bson.ObjectID, minutes int) error
 	}
 
 	CategoryRepository struct {
@@ -98,3 +99,17 @@ func (r CategoryRepository) Get(categories *[]models.Category) error {
 	}
 	return nil
 }
+
+func (r CategoryRepository) SetDuration(id bson.ObjectID, minutes int) error {
+	coll := r.db.Database("TechPower").Collection("Category")
+	filter := bson.M{"_id": id}
+	update := bson.M{"$set": bson.M{"duration": minutes}}
+	res, err := coll.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return errors.New("Failed to change category duration")
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("Category not found")
+	}
+	return nil
+}
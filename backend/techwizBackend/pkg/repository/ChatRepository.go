@@ -21,6 +21,8 @@ type (
 		GetChatByCategory(id bson.ObjectID, chat *models.Chat) error
 		AddUser(idUser bson.ObjectID, idChat bson.ObjectID) error
 		RemoveUser(idUser bson.ObjectID, idChat bson.ObjectID) error
+		SetReadMarker(idChat bson.ObjectID, idUser bson.ObjectID, marker models.ReadMarker) error
+		GetContacts(idUser bson.ObjectID) ([]bson.ObjectID, error)
 	}
 
 	ChatRepository struct {
@@ -166,3 +168,53 @@ func (r ChatRepository) RemoveUser(idUser bson.ObjectID, idChat bson.ObjectID) e
 
 	return nil
 }
+
+// SetReadMarker moves the marker of the member forward, an older message is ignored
+func (r ChatRepository) SetReadMarker(idChat bson.ObjectID, idUser bson.ObjectID, marker models.ReadMarker) error {
+	coll := r.db.Database("TechPower").Collection("Chats")
+	field := "read_by." + idUser.Hex()
+	filter := bson.M{
+		"_id":        idChat,
+		"members_id": idUser,
+		"$or": bson.A{
+			bson.M{field: bson.M{"$exists": false}},
+			bson.M{field + ".message_id": bson.M{"$lt": marker.MessageId}},
+		},
+	}
+	update := bson.M{"$set": bson.M{field: marker}}
+
+	if _, err := coll.UpdateOne(context.TODO(), filter, update); err != nil {
+		return errors.New("Failed to mark chat read")
+	}
+	return nil
+}
+
+// GetContacts returns the users who share a chat with the user
+func (r ChatRepository) GetContacts(idUser bson.ObjectID) ([]bson.ObjectID, error) {
+	coll := r.db.Database("TechPower").Collection("Chats")
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.M{"members_id": idUser}}},
+		bson.D{{Key: "$unwind", Value: "$members_id"}},
+		bson.D{{Key: "$match", Value: bson.M{"members_id": bson.M{"$ne": idUser}}}},
+		bson.D{{Key: "$group", Value: bson.M{"_id": "$members_id"}}},
+	}
+
+	cursor, err := coll.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return nil, errors.New("Failed to get contacts")
+	}
+	defer cursor.Close(context.TODO())
+
+	var members []struct {
+		Id bson.ObjectID `bson:"_id"`
+	}
+	if err = cursor.All(context.TODO(), &members); err != nil {
+		return nil, errors.New("Failed to get contacts")
+	}
+
+	contacts := make([]bson.ObjectID, len(members))
+	for i, member := range members {
+		contacts[i] = member.Id
+	}
+	return contacts, nil
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"techwizBackend/pkg/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type (
+	IDocumentRepository interface {
+		Save(document *models.Document) error
+		Get(requestId bson.ObjectID, kind string, document *models.Document) error
+		GetByRequest(requestId bson.ObjectID, documents *[]models.Document) error
+		GetTemplate(tmpl *models.DocumentTemplate) error
+		SaveTemplate(tmpl *models.DocumentTemplate) error
+	}
+
+	DocumentRepository struct {
+		db *mongo.Client
+	}
+)
+
+// the company has a single template
+const documentTemplateId = "default"
+
+func NewDocumentRepository(db *mongo.Client) *DocumentRepository {
+	return &DocumentRepository{db: db}
+}
+
+// Save replaces the document of the same kind, so a request keeps only the latest version
+func (r DocumentRepository) Save(document *models.Document) error {
+	coll := r.db.Database("TechPower").Collection("Documents")
+	filter := bson.M{"request_id": document.RequestId, "kind": document.Kind}
+	update := bson.M{"$set": bson.M{
+		"file_name":  document.FileName,
+		"content":    document.Content,
+		"created_at": document.CreatedAt,
+	}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	if err := coll.FindOneAndUpdate(context.TODO(), filter, update, opts).Decode(document); err != nil {
+		return errors.New("Failed to save document")
+	}
+	return nil
+}
+
+func (r DocumentRepository) Get(requestId bson.ObjectID, kind string, document *models.Document) error {
+	coll := r.db.Database("TechPower").Collection("Documents")
+	filter := bson.M{"request_id": requestId, "kind": kind}
+
+	if err := coll.FindOne(context.TODO(), filter).Decode(document); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errors.New("Document not found")
+		}
+		return err
+	}
+	return nil
+}
+
+func (r DocumentRepository) GetByRequest(requestId bson.ObjectID, documents *[]models.Document) error {
+	coll := r.db.Database("TechPower").Collection("Documents")
+	// content is not needed for the list
+	opts := options.Find().SetProjection(bson.M{"content": 0})
+
+	cursor, err := coll.Find(context.TODO(), bson.M{"request_id": requestId}, opts)
+	if err != nil {
+		return errors.New("Documents not found")
+	}
+	defer cursor.Close(context.TODO())
+
+	if err = cursor.All(context.TODO(), documents); err != nil {
+		return errors.New("Failed to get documents")
+	}
+	return nil
+}
+
+func (r DocumentRepository) GetTemplate(tmpl *models.DocumentTemplate) error {
+	coll := r.db.Database("TechPower").Collection("DocumentTemplates")
+
+	if err := coll.FindOne(context.TODO(), bson.M{"_id": documentTemplateId}).Decode(tmpl); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errors.New("Template not found")
+		}
+		return err
+	}
+	return nil
+}
+
+func (r DocumentRepository) SaveTemplate(tmpl *models.DocumentTemplate) error {
+	coll := r.db.Database("TechPower").Collection("DocumentTemplates")
+	opts := options.Replace().SetUpsert(true)
+
+	if _, err := coll.ReplaceOne(context.TODO(), bson.M{"_id": documentTemplateId}, tmpl, opts); err != nil {
+		return errors.New("Failed to save template")
+	}
+	return nil
+}
@@ -1,11 +1,14 @@
 package repository
 
 import (
+	"context"
 	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 	"log"
 	"os"
+	"techwizBackend/pkg/models"
 )
 
 func New() *mongo.Client {
@@ -23,3 +26,65 @@ func New() *mongo.Client {
 	}
 	return client
 }
+
+// CreateIndexes creates the indexes that the repositories rely on, existing indexes are kept
+func CreateIndexes(client *mongo.Client) error {
+	db := client.Database("TechPower")
+	indexes := map[string][]mongo.IndexModel{
+		"Reviews": {
+			{Keys: bson.D{{Key: "request_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "master_id", Value: 1}}},
+		},
+		"Jobs": {
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "run_at", Value: 1}}},
+			{Keys: bson.D{{Key: "key", Value: 1}}},
+			// a single pending job per key, also when instances schedule it at the same time
+			{
+				Keys: bson.D{{Key: "key", Value: 1}, {Key: "status", Value: 1}},
+				Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{
+					"key":    bson.M{"$exists": true},
+					"status": models.JobPending,
+				}),
+			},
+		},
+		// concurrent proposals cannot save the same revision of the quote
+		"Quotes": {
+			{Keys: bson.D{{Key: "request_id", Value: 1}, {Key: "revision", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"Notifications": {
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "read", Value: 1}, {Key: "created_at", Value: -1}}},
+		},
+		"Requests": {
+			{Keys: bson.D{{Key: "location", Value: "2dsphere"}}},
+			{Keys: bson.D{{Key: "source_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+		},
+		"Messages": {
+			{Keys: bson.D{{Key: "chat_id", Value: 1}, {Key: "_id", Value: 1}}},
+			{Keys: bson.D{{Key: "chat_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		},
+		"Routes": {
+			{Keys: bson.D{{Key: "master_id", Value: 1}, {Key: "date", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"WebhookDeliveries": {
+			{Keys: bson.D{{Key: "webhook_id", Value: 1}, {Key: "created_at", Value: -1}}},
+			{Keys: bson.D{{Key: "status", Value: 1}}},
+		},
+		// events of the websocket bus are needed only while the instances read them
+		"HubEvents": {
+			{Keys: bson.D{{Key: "created_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(3600)},
+		},
+		"ApiKeys": {
+			{Keys: bson.D{{Key: "hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"Users": {
+			{Keys: bson.D{{Key: "base", Value: "2dsphere"}}},
+		},
+	}
+
+	for coll, list := range indexes {
+		if _, err := db.Collection(coll).Indexes().CreateMany(context.TODO(), list); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,331 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"techwizBackend/pkg/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type (
+	IJobRepository interface {
+		Create(job *models.Job) error
+		Upsert(job *models.Job) error
+		Ensure(job *models.Job) error
+		GetById(id bson.ObjectID, job *models.Job) error
+		Get(filter bson.M, limit int64, jobs *[]models.Job) error
+		Acquire(owner string, lease time.Duration, skipTypes []string, job *models.Job) error
+		Extend(job *models.Job, lease time.Duration) error
+		GetExpired(jobs *[]models.Job) error
+		Complete(job *models.Job, next *time.Time, message string) error
+		Fail(job *models.Job, message string, retryAt *time.Time) error
+		Retry(id bson.ObjectID) error
+		Cancel(id bson.ObjectID) error
+		CancelByKey(key string) error
+		SetCron(id bson.ObjectID, cron string, runAt time.Time) error
+	}
+
+	JobRepository struct {
+		db *mongo.Client
+	}
+)
+
+func NewJobRepository(db *mongo.Client) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+func (r JobRepository) Create(job *models.Job) error {
+	coll := r.db.Database("TechPower").Collection("Jobs")
+	res, err := coll.InsertOne(context.TODO(), job)
+	if err != nil {
+		return errors.New("Failed to create job")
+	}
+	job.Id = res.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+// Upsert replaces the pending job with the same key, so the job is moved instead of duplicated
+func (r JobRepository) Upsert(job *models.Job) error {
+	coll := r.db.Database("TechPower").Collection("Jobs")
+	filter := bson.M{"key": job.Key, "status": models.JobPending}
+	update := bson.M{
+		"$set": bson.M{
+			"type":         job.Type,
+			"payload":      job.Payload,
+			"run_at":       job.RunAt,
+			"max_attempts": job.MaxAttempts,
+			"attempts":     0,
+		},
+		"$setOnInsert": bson.M{"status": models.JobPending, "created_at": job.CreatedAt},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	err := coll.FindOneAndUpdate(context.TODO(), filter, update, opts).Decode(job)
+	if mongo.IsDuplicateKeyError(err) {
+		// the pending job is unique by key, another instance has just created it and it is moved now
+		err = coll.FindOneAndUpdate(context.TODO(), filter, update, opts.SetUpsert(false)).Decode(job)
+	}
+	if err != nil {
+		return errors.New("Failed to schedule job")
+	}
+	return nil
+}
+
+// Ensure creates the job unless a pending or running job with the same key exists,
+// the existing one is returned then. Instances that start together create it only once
+func (r JobRepository) Ensure(job *models.Job) error {
+	coll := r.db.Database("TechPower").Collection("Jobs")
+	filter := bson.M{"key": job.Key, "status": bson.M{"$in": bson.A{models.JobPending, models.JobRunning}}}
+	update := bson.M{"$setOnInsert": bson.M{
+		"type":         job.Type,
+		"cron":         job.Cron,
+		"status":       models.JobPending,
+		"run_at":       job.RunAt,
+		"attempts":     0,
+		"max_attempts": job.MaxAttempts,
+		"created_at":   job.CreatedAt,
+	}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	err := coll.FindOneAndUpdate(context.TODO(), filter, update, opts).Decode(job)
+	if mongo.IsDuplicateKeyError(err) {
+		// the pending job is unique by key, another instance has just created it
+		err = coll.FindOne(context.TODO(), filter).Decode(job)
+	}
+	if err != nil {
+		return errors.New("Failed to schedule job")
+	}
+	return nil
+}
+
+func (r JobRepository) GetById(id bson.ObjectID, job *models.Job) error {
+	coll := r.db.Database("TechPower").Collection("Jobs")
+
+	if err := coll.FindOne(context.TODO(), bson.M{"_id": id}).Decode(job); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errors.New("Job not found")
+		}
+		return err
+	}
+	return nil
+}
+
+func (r JobRepository) Get(filter bson.M, limit int64, jobs *[]models.Job) error {
+	coll := r.db.Database("TechPower").Collection("Jobs")
+	opts := options.Find().SetSort(bson.M{"run_at": -1}).SetLimit(limit)
+
+	cursor, err := coll.Find(context.TODO(), filter, opts)
+	if err != nil {
+		return errors.New("Jobs not found")
+	}
+	defer cursor.Close(context.TODO())
+
+	if err = cursor.All(context.TODO(), jobs); err != nil {
+		return errors.New("Failed to get jobs")
+	}
+	return nil
+}
+
+// Acquire leases the earliest due job, a running job with an expired lease is taken over
+// while it has attempts left. Jobs of skipTypes are left for later.
+// mongo.ErrNoDocuments is returned when nothing is due
+func (r JobRepository) Acquire(owner string, lease time.Duration, skipTypes []string, job *models.Job) error {
+	coll := r.db.Database("TechPower").Collection("Jobs")
+	now := time.Now()
+	filter := bson.M{"$or": bson.A{
+		bson.M{"status": models.JobPending, "run_at": bson.M{"$lte": now}},
+		bson.M{
+			"status":       models.JobRunning,
+			"locked_until": bson.M{"$lt": now},
+			"$expr":        bson.M{"$lt": bson.A{"$attempts", "$max_attempts"}},
+		},
+	}}
+	if len(skipTypes) > 0 {
+		filter["type"] = bson.M{"$nin": skipTypes}
+	}
+	update := bson.M{
+		"$set": bson.M{"status": models.JobRunning, "locked_by": owner, "locked_until": now.Add(lease)},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().SetSort(bson.M{"run_at": 1}).SetReturnDocument(options.After)
+
+	return coll.FindOneAndUpdate(context.TODO(), filter, update, opts).Decode(job)
+}
+
+// Extend renews the lease of the running job, so a long job is not taken over by another instance
+func (r JobRepository) Extend(job *models.Job, lease time.Duration) error {
+	coll := r.db.Database("TechPower").Collection("Jobs")
+	filter := bson.M{"_id": job.Id, "status": models.JobRunning, "locked_by": job.LockedBy}
+	update := bson.M{"$set": bson.M{"locked_until": time.Now().Add(lease)}}
+
+	res, err := coll.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return errors.New("Failed to extend job lease")
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("Job lease is lost")
+	}
+	return nil
+}
+
+// GetExpired returns running jobs with an expired lease that have used all attempts,
+// Acquire does not take them over
+func (r JobRepository) GetExpired(jobs *[]models.Job) error {
+	coll := r.db.Database("TechPower").Collection("Jobs")
+	filter := bson.M{
+		"status":       models.JobRunning,
+		"locked_until": bson.M{"$lt": time.Now()},
+		"$expr":        bson.M{"$gte": bson.A{"$attempts", "$max_attempts"}},
+	}
+
+	cursor, err := coll.Find(context.TODO(), filter)
+	if err != nil {
+		return errors.New("Jobs not found")
+	}
+	defer cursor.Close(context.TODO())
+
+	if err = cursor.All(context.TODO(), jobs); err != nil {
+		return errors.New("Failed to get jobs")
+	}
+	return nil
+}
+
+// Complete finishes the job, a recurring job is scheduled again at next.
+// message keeps the error of a recurring job that has used all attempts.
+// As in Fail, the job is canceled if a newer pending job with the key exists
+func (r JobRepository) Complete(job *models.Job, next *time.Time, message string) error {
+	coll := r.db.Database("TechPower").Collection("Jobs")
+	filter := bson.M{"_id": job.Id, "status": models.JobRunning, "locked_by": job.LockedBy}
+	set := bson.M{"status": models.JobDone, "finished_at": time.Now()}
+	unset := bson.M{"locked_by": "", "locked_until": ""}
+	if next != nil {
+		set["status"] = models.JobPending
+		set["run_at"] = *next
+		set["attempts"] = 0
+	}
+	if message != "" {
+		set["last_error"] = message
+	} else {
+		unset["last_error"] = ""
+	}
+
+	res, err := coll.UpdateOne(context.TODO(), filter, bson.M{"$set": set, "$unset": unset})
+	if mongo.IsDuplicateKeyError(err) {
+		return r.supersede(job, message)
+	}
+	if err != nil {
+		return errors.New("Failed to complete job")
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("Job lease is lost")
+	}
+	return nil
+}
+
+// Fail schedules the retry at retryAt, without it the job is failed for good.
+// A retry of a job whose key has a newer pending job cancels the job
+func (r JobRepository) Fail(job *models.Job, message string, retryAt *time.Time) error {
+	coll := r.db.Database("TechPower").Collection("Jobs")
+	filter := bson.M{"_id": job.Id, "status": models.JobRunning, "locked_by": job.LockedBy}
+	set := bson.M{"status": models.JobFailed, "last_error": message, "finished_at": time.Now()}
+	if retryAt != nil {
+		set = bson.M{"status": models.JobPending, "last_error": message, "run_at": *retryAt}
+	}
+	update := bson.M{"$set": set, "$unset": bson.M{"locked_by": "", "locked_until": ""}}
+
+	res, err := coll.UpdateOne(context.TODO(), filter, update)
+	if mongo.IsDuplicateKeyError(err) {
+		return r.supersede(job, message)
+	}
+	if err != nil {
+		return errors.New("Failed to fail job")
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("Job lease is lost")
+	}
+	return nil
+}
+
+// supersede cancels the running job that cannot return to pending,
+// a newer pending job with the same key has been scheduled while it was running
+func (r JobRepository) supersede(job *models.Job, message string) error {
+	coll := r.db.Database("TechPower").Collection("Jobs")
+	filter := bson.M{"_id": job.Id, "status": models.JobRunning, "locked_by": job.LockedBy}
+	set := bson.M{"status": models.JobCanceled, "finished_at": time.Now(), "last_error": "superseded by a newer job"}
+	if message != "" {
+		set["last_error"] = message + ", superseded by a newer job"
+	}
+	update := bson.M{"$set": set, "$unset": bson.M{"locked_by": "", "locked_until": ""}}
+
+	res, err := coll.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return errors.New("Failed to cancel job")
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("Job lease is lost")
+	}
+	return nil
+}
+
+func (r JobRepository) Retry(id bson.ObjectID) error {
+	coll := r.db.Database("TechPower").Collection("Jobs")
+	filter := bson.M{"_id": id, "status": bson.M{"$in": bson.A{models.JobFailed, models.JobCanceled, models.JobDone}}}
+	update := bson.M{
+		"$set":   bson.M{"status": models.JobPending, "run_at": time.Now(), "attempts": 0},
+		"$unset": bson.M{"finished_at": ""},
+	}
+
+	res, err := coll.UpdateOne(context.TODO(), filter, update)
+	if mongo.IsDuplicateKeyError(err) {
+		// the job is already finished, the newer pending job with the key runs instead
+		return errors.New("A pending job with the same key exists")
+	}
+	if err != nil {
+		return errors.New("Failed to retry job")
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("Only finished jobs can be retried")
+	}
+	return nil
+}
+
+func (r JobRepository) Cancel(id bson.ObjectID) error {
+	coll := r.db.Database("TechPower").Collection("Jobs")
+	filter := bson.M{"_id": id, "status": bson.M{"$in": bson.A{models.JobPending, models.JobFailed}}}
+	update := bson.M{"$set": bson.M{"status": models.JobCanceled, "finished_at": time.Now()}}
+
+	res, err := coll.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return errors.New("Failed to cancel job")
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("Only pending or failed jobs can be canceled")
+	}
+	return nil
+}
+
+// CancelByKey cancels the pending job with the key, it is not an error if there is none
+func (r JobRepository) CancelByKey(key string) error {
+	coll := r.db.Database("TechPower").Collection("Jobs")
+	filter := bson.M{"key": key, "status": models.JobPending}
+	update := bson.M{"$set": bson.M{"status": models.JobCanceled, "finished_at": time.Now()}}
+
+	if _, err := coll.UpdateMany(context.TODO(), filter, update); err != nil {
+		return errors.New("Failed to cancel job")
+	}
+	return nil
+}
+
+func (r JobRepository) SetCron(id bson.ObjectID, cron string, runAt time.Time) error {
+	coll := r.db.Database("TechPower").Collection("Jobs")
+	update := bson.M{"$set": bson.M{"cron": cron, "run_at": runAt}}
+
+	if _, err := coll.UpdateOne(context.TODO(), bson.M{"_id": id}, update); err != nil {
+		return errors.New("Failed to change job schedule")
+	}
+	return nil
+}
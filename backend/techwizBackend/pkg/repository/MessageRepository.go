@@ -3,7 +3,9 @@ package repository
 import (
 	"context"
 	"errors"
+	"slices"
 	"techwizBackend/pkg/models"
+	"time"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
@@ -13,7 +15,17 @@ import (
 type (
 	IMessageRepository interface {
 		Save(*models.Message) error
-		GetMessageByChat(chat_id bson.ObjectID, messages *[]models.Message) error
+		GetPage(chatId bson.ObjectID, before *models.MessageAnchor, after *models.MessageAnchor, limit int64, messages *[]models.Message) error
+		GetAfter(chatIds []bson.ObjectID, after *models.MessageAnchor, undeliveredTo *bson.ObjectID, limit int64, messages *[]models.Message) error
+		MarkDelivered(messageIds []bson.ObjectID, userIds []bson.ObjectID) error
+		SetCursor(userId bson.ObjectID, cursor models.MessageAnchor) error
+		GetCursor(userId bson.ObjectID) (*models.MessageAnchor, error)
+		GetById(id bson.ObjectID, message *models.Message) error
+		Edit(id bson.ObjectID, text string, previous models.MessageEdit) error
+		Delete(id bson.ObjectID, previous models.MessageEdit) error
+		GetLast(chatId bson.ObjectID, message *models.Message) error
+		GetNewest(chatIds []bson.ObjectID, message *models.Message) error
+		CountUnread(userId bson.ObjectID, markers map[bson.ObjectID]*bson.ObjectID) (map[bson.ObjectID]int64, error)
 	}
 
 	MessageRepository struct {
@@ -37,20 +49,253 @@ func (r MessageRepository) Save(message *models.Message) error {
 	return nil
 }
 
-func (r MessageRepository) GetMessageByChat(chat_id bson.ObjectID, messages *[]models.Message) error {
+// GetPage returns up to limit messages of the chat older than before or newer than after, the newest first.
+// The anchor time is compared with created_at, equal times are ordered by id
+func (r MessageRepository) GetPage(chatId bson.ObjectID, before *models.MessageAnchor, after *models.MessageAnchor, limit int64, messages *[]models.Message) error {
 	coll := r.db.Database("TechPower").Collection("Messages")
-	filter := bson.M{"chat_id": chat_id}
-	opts := options.Find().SetSort(bson.D{{"created_at", 1}})
+	filter := bson.M{"chat_id": chatId}
+	order := -1
+
+	if before != nil {
+		filter["$or"] = anchorFilter(before, "$lt")
+	} else if after != nil {
+		filter["$or"] = anchorFilter(after, "$gt")
+		// the page next to the anchor is taken in ascending order and reversed
+		order = 1
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: order}, {Key: "_id", Value: order}}).
+		SetProjection(bson.M{"delivered_to": 0}).
+		SetLimit(limit)
+
+	cursor, err := coll.Find(context.TODO(), filter, opts)
+	if err != nil {
+		return errors.New("Failed to query messages: " + err.Error())
+	}
+	defer cursor.Close(context.TODO())
+
+	if err = cursor.All(context.TODO(), messages); err != nil {
+		return errors.New("Failed to decode messages: " + err.Error())
+	}
+	if order == 1 {
+		slices.Reverse(*messages)
+	}
+	return nil
+}
+
+func anchorFilter(anchor *models.MessageAnchor, op string) bson.A {
+	if anchor.Id == nil {
+		return bson.A{bson.M{"created_at": bson.M{op: *anchor.At}}}
+	}
+	return bson.A{
+		bson.M{"created_at": bson.M{op: *anchor.At}},
+		bson.M{"created_at": *anchor.At, "_id": bson.M{op: *anchor.Id}},
+	}
+}
+
+// GetAfter returns the messages of the chats newer than the anchor, the oldest first.
+// Messages are ordered by created_at and id as in GetPage: ids of different instances
+// created in the same second are not in the order of the messages.
+// With undeliveredTo only the messages that the user didn't get are returned, delivered_to is not loaded
+func (r MessageRepository) GetAfter(chatIds []bson.ObjectID, after *models.MessageAnchor, undeliveredTo *bson.ObjectID, limit int64, messages *[]models.Message) error {
+	coll := r.db.Database("TechPower").Collection("Messages")
+	filter := bson.M{"chat_id": bson.M{"$in": chatIds}}
+	if after != nil {
+		filter["$or"] = anchorFilter(after, "$gt")
+	}
+	if undeliveredTo != nil {
+		filter["delivered_to"] = bson.M{"$ne": *undeliveredTo}
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetProjection(bson.M{"delivered_to": 0}).
+		SetLimit(limit)
 
 	cursor, err := coll.Find(context.TODO(), filter, opts)
 	if err != nil {
 		return errors.New("Failed to query messages: " + err.Error())
 	}
+	defer cursor.Close(context.TODO())
 
 	if err = cursor.All(context.TODO(), messages); err != nil {
 		return errors.New("Failed to decode messages: " + err.Error())
 	}
+	return nil
+}
+
+func (r MessageRepository) MarkDelivered(messageIds []bson.ObjectID, userIds []bson.ObjectID) error {
+	coll := r.db.Database("TechPower").Collection("Messages")
+	filter := bson.M{"_id": bson.M{"$in": messageIds}}
+	update := bson.M{"$addToSet": bson.M{"delivered_to": bson.M{"$each": userIds}}}
+
+	if _, err := coll.UpdateMany(context.TODO(), filter, update); err != nil {
+		return errors.New("Failed to mark messages delivered")
+	}
+	return nil
+}
+
+// SetCursor moves the delivery cursor of the user forward by created_at and id, never back
+func (r MessageRepository) SetCursor(userId bson.ObjectID, cursor models.MessageAnchor) error {
+	if cursor.Id == nil || cursor.At == nil {
+		return errors.New("Delivery cursor needs the message id and time")
+	}
+
+	coll := r.db.Database("TechPower").Collection("DeliveryCursors")
+	filter := bson.M{"_id": userId, "$or": bson.A{
+		bson.M{"last_at": bson.M{"$exists": false}},
+		bson.M{"last_at": bson.M{"$lt": *cursor.At}},
+		bson.M{"last_at": *cursor.At, "last_id": bson.M{"$lt": *cursor.Id}},
+	}}
+	update := bson.M{"$set": bson.M{"last_id": *cursor.Id, "last_at": *cursor.At, "updated_at": time.Now()}}
+
+	_, err := coll.UpdateOne(context.TODO(), filter, update, options.UpdateOne().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// the cursor exists and is already further
+		return nil
+	}
+	if err != nil {
+		return errors.New("Failed to update delivery cursor")
+	}
+	return nil
+}
+
+// GetCursor returns the delivery cursor, nil if the user has never synced.
+// A cursor saved without the time uses the time of its id
+func (r MessageRepository) GetCursor(userId bson.ObjectID) (*models.MessageAnchor, error) {
+	coll := r.db.Database("TechPower").Collection("DeliveryCursors")
+
+	var cursor struct {
+		LastId bson.ObjectID `bson:"last_id"`
+		LastAt *time.Time    `bson:"last_at"`
+	}
+	if err := coll.FindOne(context.TODO(), bson.M{"_id": userId}).Decode(&cursor); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, errors.New("Failed to get delivery cursor")
+	}
+	if cursor.LastAt == nil {
+		at := cursor.LastId.Timestamp()
+		cursor.LastAt = &at
+	}
+	return &models.MessageAnchor{Id: &cursor.LastId, At: cursor.LastAt}, nil
+}
+
+func (r MessageRepository) GetById(id bson.ObjectID, message *models.Message) error {
+	coll := r.db.Database("TechPower").Collection("Messages")
+
+	if err := coll.FindOne(context.TODO(), bson.M{"_id": id}).Decode(message); err != nil {
+		return errors.New("Message not found")
+	}
+	return nil
+}
+
+func (r MessageRepository) GetLast(chatId bson.ObjectID, message *models.Message) error {
+	coll := r.db.Database("TechPower").Collection("Messages")
+	opts := options.FindOne().SetSort(bson.M{"_id": -1})
+
+	if err := coll.FindOne(context.TODO(), bson.M{"chat_id": chatId}, opts).Decode(message); err != nil {
+		return errors.New("Message not found")
+	}
+	return nil
+}
+
+// GetNewest returns the last message of any of the chats in the order of GetAfter
+func (r MessageRepository) GetNewest(chatIds []bson.ObjectID, message *models.Message) error {
+	coll := r.db.Database("TechPower").Collection("Messages")
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+
+	if err := coll.FindOne(context.TODO(), bson.M{"chat_id": bson.M{"$in": chatIds}}, opts).Decode(message); err != nil {
+		return errors.New("Message not found")
+	}
+	return nil
+}
+
+// CountUnread counts the messages of the others after the read marker of every chat in one query,
+// markers has all chats of the user, nil = nothing is read yet. Deleted messages are not counted
+func (r MessageRepository) CountUnread(userId bson.ObjectID, markers map[bson.ObjectID]*bson.ObjectID) (map[bson.ObjectID]int64, error) {
+	res := make(map[bson.ObjectID]int64, len(markers))
+	if len(markers) == 0 {
+		return res, nil
+	}
+
+	unread := bson.A{}
+	var unreadChats bson.A
+	for chatId, after := range markers {
+		if after == nil {
+			unreadChats = append(unreadChats, chatId)
+			continue
+		}
+		unread = append(unread, bson.M{"chat_id": chatId, "_id": bson.M{"$gt": *after}})
+	}
+	if len(unreadChats) > 0 {
+		unread = append(unread, bson.M{"chat_id": bson.M{"$in": unreadChats}})
+	}
+
+	coll := r.db.Database("TechPower").Collection("Messages")
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.M{
+			"$or":        unread,
+			"sender_id":  bson.M{"$ne": userId},
+			"deleted_at": bson.M{"$exists": false},
+		}}},
+		bson.D{{Key: "$group", Value: bson.M{"_id": "$chat_id", "count": bson.M{"$sum": 1}}}},
+	}
 
+	cursor, err := coll.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return nil, errors.New("Failed to count unread messages")
+	}
 	defer cursor.Close(context.TODO())
+
+	var rows []struct {
+		ChatId bson.ObjectID `bson:"_id"`
+		Count  int64         `bson:"count"`
+	}
+	if err := cursor.All(context.TODO(), &rows); err != nil {
+		return nil, errors.New("Failed to count unread messages")
+	}
+	for _, row := range rows {
+		res[row.ChatId] = row.Count
+	}
+	return res, nil
+}
+
+// Edit replaces the text if it wasn't changed since it was read, the previous one is kept in edits
+func (r MessageRepository) Edit(id bson.ObjectID, text string, previous models.MessageEdit) error {
+	coll := r.db.Database("TechPower").Collection("Messages")
+	filter := bson.M{"_id": id, "text": previous.Text, "deleted_at": bson.M{"$exists": false}}
+	update := bson.M{
+		"$set":  bson.M{"text": text, "edited_at": previous.ReplacedAt},
+		"$push": bson.M{"edits": previous},
+	}
+
+	res, err := coll.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return errors.New("Failed to edit message")
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("Message was changed or deleted")
+	}
+	return nil
+}
+
+// Delete leaves a tombstone without the text, the text is kept in edits
+func (r MessageRepository) Delete(id bson.ObjectID, previous models.MessageEdit) error {
+	coll := r.db.Database("TechPower").Collection("Messages")
+	filter := bson.M{"_id": id, "deleted_at": bson.M{"$exists": false}}
+	update := bson.M{
+		"$set":   bson.M{"deleted_at": previous.ReplacedAt, "deleted_by": previous.ReplacedBy},
+		"$unset": bson.M{"text": ""},
+		"$push":  bson.M{"edits": previous},
+	}
+
+	res, err := coll.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return errors.New("Failed to delete message")
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("Message is already deleted")
+	}
 	return nil
 }
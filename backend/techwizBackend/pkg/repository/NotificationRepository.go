@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"techwizBackend/pkg/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type (
+	INotificationRepository interface {
+		Create(notifications []models.Notification) error
+		GetByUser(userId bson.ObjectID, unread bool, limit int64, notifications *[]models.Notification) error
+		MarkRead(userId bson.ObjectID, ids []bson.ObjectID) (int64, error)
+		CountUnread(userId bson.ObjectID) (int64, error)
+	}
+
+	NotificationRepository struct {
+		db *mongo.Client
+	}
+)
+
+func NewNotificationRepository(db *mongo.Client) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+func (r NotificationRepository) Create(notifications []models.Notification) error {
+	coll := r.db.Database("TechPower").Collection("Notifications")
+	res, err := coll.InsertMany(context.TODO(), notifications)
+	if err != nil {
+		return errors.New("Failed to create notifications")
+	}
+	for i, id := range res.InsertedIDs {
+		notifications[i].Id = id.(bson.ObjectID)
+	}
+	return nil
+}
+
+// GetByUser returns the latest notifications first
+func (r NotificationRepository) GetByUser(userId bson.ObjectID, unread bool, limit int64, notifications *[]models.Notification) error {
+	coll := r.db.Database("TechPower").Collection("Notifications")
+	filter := bson.M{"user_id": userId}
+	if unread {
+		filter["read"] = false
+	}
+	opts := options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(limit)
+
+	cursor, err := coll.Find(context.TODO(), filter, opts)
+	if err != nil {
+		return errors.New("Notifications not found")
+	}
+	defer cursor.Close(context.TODO())
+
+	if err = cursor.All(context.TODO(), notifications); err != nil {
+		return errors.New("Failed to get notifications")
+	}
+	return nil
+}
+
+// MarkRead marks the notifications of the user, all of them if ids are empty
+func (r NotificationRepository) MarkRead(userId bson.ObjectID, ids []bson.ObjectID) (int64, error) {
+	coll := r.db.Database("TechPower").Collection("Notifications")
+	filter := bson.M{"user_id": userId, "read": false}
+	if len(ids) > 0 {
+		filter["_id"] = bson.M{"$in": ids}
+	}
+	update := bson.M{"$set": bson.M{"read": true, "read_at": time.Now()}}
+
+	res, err := coll.UpdateMany(context.TODO(), filter, update)
+	if err != nil {
+		return 0, errors.New("Failed to mark notifications")
+	}
+	return res.ModifiedCount, nil
+}
+
+func (r NotificationRepository) CountUnread(userId bson.ObjectID) (int64, error) {
+	coll := r.db.Database("TechPower").Collection("Notifications")
+	count, err := coll.CountDocuments(context.TODO(), bson.M{"user_id": userId, "read": false})
+	if err != nil {
+		return 0, errors.New("Failed to count notifications")
+	}
+	return count, nil
+}
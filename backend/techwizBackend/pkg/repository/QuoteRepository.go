@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"techwizBackend/pkg/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ErrRevisionTaken means another proposal has just saved the same revision of the quote
+var ErrRevisionTaken = errors.New("Quote revision is already taken")
+
+type (
+	IQuoteRepository interface {
+		Create(quote *models.Quote) error
+		GetByRequest(requestId bson.ObjectID, quotes *[]models.Quote) error
+		GetPending(requestId bson.ObjectID, quote *models.Quote) error
+		CountByRequest(requestId bson.ObjectID) (int64, error)
+		Supersede(requestId bson.ObjectID) error
+		Decide(quote *models.Quote) error
+	}
+
+	QuoteRepository struct {
+		db *mongo.Client
+	}
+)
+
+func NewQuoteRepository(db *mongo.Client) *QuoteRepository {
+	return &QuoteRepository{db: db}
+}
+
+func (r QuoteRepository) Create(quote *models.Quote) error {
+	coll := r.db.Database("TechPower").Collection("Quotes")
+	res, err := coll.InsertOne(context.TODO(), quote)
+	if err != nil {
+		// request_id and revision are a unique index
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrRevisionTaken
+		}
+		return errors.New("Failed to create quote")
+	}
+	quote.Id = res.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r QuoteRepository) GetByRequest(requestId bson.ObjectID, quotes *[]models.Quote) error {
+	coll := r.db.Database("TechPower").Collection("Quotes")
+	filter := bson.M{"request_id": requestId}
+	opts := options.Find().SetSort(bson.D{{Key: "revision", Value: 1}})
+
+	cursor, err := coll.Find(context.TODO(), filter, opts)
+	if err != nil {
+		return errors.New("Quotes not found")
+	}
+	defer cursor.Close(context.TODO())
+
+	if err = cursor.All(context.TODO(), quotes); err != nil {
+		return errors.New("Failed to get quotes")
+	}
+	return nil
+}
+
+func (r QuoteRepository) GetPending(requestId bson.ObjectID, quote *models.Quote) error {
+	coll := r.db.Database("TechPower").Collection("Quotes")
+	filter := bson.M{"request_id": requestId, "status": models.QuotePending}
+
+	if err := coll.FindOne(context.TODO(), filter).Decode(quote); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errors.New("Pending quote not found")
+		}
+		return err
+	}
+	return nil
+}
+
+func (r QuoteRepository) CountByRequest(requestId bson.ObjectID) (int64, error) {
+	coll := r.db.Database("TechPower").Collection("Quotes")
+	return coll.CountDocuments(context.TODO(), bson.M{"request_id": requestId})
+}
+
+// Supersede marks all pending revisions of the request as replaced
+func (r QuoteRepository) Supersede(requestId bson.ObjectID) error {
+	coll := r.db.Database("TechPower").Collection("Quotes")
+	filter := bson.M{"request_id": requestId, "status": models.QuotePending}
+	update := bson.M{"$set": bson.M{"status": models.QuoteSuperseded}}
+
+	if _, err := coll.UpdateMany(context.TODO(), filter, update); err != nil {
+		return errors.New("Failed to supersede quotes")
+	}
+	return nil
+}
+
+func (r QuoteRepository) Decide(quote *models.Quote) error {
+	coll := r.db.Database("TechPower").Collection("Quotes")
+	// only a pending quote can be decided, so two decisions cannot race
+	filter := bson.M{"_id": quote.Id, "status": models.QuotePending}
+	update := bson.M{"$set": bson.M{
+		"status":      quote.Status,
+		"decided_by":  quote.DecidedBy,
+		"decided_via": quote.DecidedVia,
+		"comment":     quote.Comment,
+		"decided_at":  quote.DecidedAt,
+	}}
+
+	res, err := coll.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return errors.New("Failed to update quote")
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("Quote is already decided")
+	}
+	return nil
+}
@@ -6,16 +6,27 @@ import (
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"techwizBackend/pkg/models"
+	"time"
 )
 
 type (
 	IRequestRepository interface {
 		Create(request *models.Request) error
-		GetRequests(requests *[]models.Request) error
+		GetRequests(filter bson.M, requests *[]models.Request) error
 		GetRequest(id bson.ObjectID, request *models.Request) error
 		AttachMaster(requestId bson.ObjectID, userId bson.ObjectID, request *models.Request) error
 		ChangeStatus(id bson.ObjectID, status *models.Request) error
 		InSpot(id bson.ObjectID) error
+		GetRequestByToken(token string, request *models.Request) error
+		ChangePrice(id bson.ObjectID, price float64, items []models.LineItem, status models.Status) error
+		CountByWorker(workerId bson.ObjectID) (map[int]int64, error)
+		GetByWorker(workerId bson.ObjectID, from time.Time, to time.Time, requests *[]models.Request) error
+		SetLocation(id bson.ObjectID, location *models.Point) error
+		CheckIn(id bson.ObjectID, event *models.VisitEvent) error
+		CheckOut(id bson.ObjectID, event *models.VisitEvent, minutes int) error
+		GetUnflagged(codes []int, requests *[]models.Request) error
+		FlagSla(id bson.ObjectID, breach *models.SlaBreach) error
+		ChangeDateTime(id bson.ObjectID, datetime time.Time) error
 	}
 
 	RequestRepository struct {
@@ -39,9 +50,10 @@ func (r RequestRepository) Create(request *models.Request) error {
 	return nil
 }
 
-func (r RequestRepository) GetRequests(requests *[]models.Request) error {
+func (r RequestRepository) GetRequests(filter bson.M, requests *[]models.Request) error {
 	coll := r.db.Database("TechPower").Collection("Requests")
 	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: filter}},
 		// Выполняем $lookup для объединения с коллекцией Category
 		bson.D{{
 			"$lookup",
@@ -165,7 +177,10 @@ func (r RequestRepository) GetRequest(id bson.ObjectID, request *models.Request)
 func (r RequestRepository) AttachMaster(requestId bson.ObjectID, userId bson.ObjectID, request *models.Request) error {
 	coll := r.db.Database("TechPower").Collection("Requests")
 	filter := bson.M{"_id": requestId}
-	update := bson.M{"$set": bson.M{"worker_id": userId, "status.code": 2, "commission": request.Commission}}
+	update := bson.M{
+		"$set":   bson.M{"worker_id": userId, "status.code": 2, "commission": request.Commission, "status_at": time.Now()},
+		"$unset": bson.M{"sla": ""},
+	}
 
 	if _, err := coll.UpdateOne(context.TODO(), filter, update); err != nil {
 		return err
@@ -184,10 +199,12 @@ func (r RequestRepository) ChangeStatus(id bson.ObjectID, status *models.Request
 	if status.Status.Code == 0 && status.Status.Reason == "" && status.Status.PriceIsBail == 0 {
 		return fmt.Errorf("status is empty or invalid")
 	}
-	update := bson.M{"$set": bson.M{"status": status.Status}}
+	set := bson.M{"status": status.Status, "status_at": time.Now()}
 	if status.Status.Code == 4 && status.Price >= 0 {
-		update = bson.M{"$set": bson.M{"price": status.Price, "status": status.Status}}
+		set["price"] = status.Price
+		set["items"] = status.Items
 	}
+	update := bson.M{"$set": set, "$unset": bson.M{"sla": ""}}
 
 	if _, err := coll.UpdateOne(context.TODO(), filter, update); err != nil {
 		return fmt.Errorf("%s", err.Error())
@@ -199,7 +216,7 @@ func (r RequestRepository) ChangeStatus(id bson.ObjectID, status *models.Request
 		if err := r.GetRequest(id, &tmpReq); err != nil {
 			return fmt.Errorf("%s", err.Error())
 		}
-		if _, err := userColl.UpdateOne(context.TODO(), bson.M{"_id": tmpReq.Worker.Id}, bson.M{"$set": bson.M{"balance": tmpReq.Worker.Balance + (tmpReq.Price - tmpReq.Price*tmpReq.Worker.Commission)}}); err != nil {
+		if _, err := userColl.UpdateOne(context.TODO(), bson.M{"_id": tmpReq.Worker.Id}, bson.M{"$inc": bson.M{"balance": tmpReq.Price - tmpReq.CommissionTotal()}}); err != nil {
 			return fmt.Errorf("%s", err.Error())
 		}
 	}
@@ -218,3 +235,190 @@ func (r RequestRepository) InSpot(id bson.ObjectID) error {
 
 	return nil
 }
+
+func (r RequestRepository) GetRequestByToken(token string, request *models.Request) error {
+	coll := r.db.Database("TechPower").Collection("Requests")
+	var tmpReq models.Request
+	if err := coll.FindOne(context.TODO(), bson.M{"tracking_token": token}).Decode(&tmpReq); err != nil {
+		return fmt.Errorf("Request not found")
+	}
+
+	return r.GetRequest(tmpReq.Id, request)
+}
+
+func (r RequestRepository) ChangePrice(id bson.ObjectID, price float64, items []models.LineItem, status models.Status) error {
+	coll := r.db.Database("TechPower").Collection("Requests")
+	filter := bson.M{"_id": id}
+	update := bson.M{
+		"$set":   bson.M{"price": price, "items": items, "status": status, "status_at": time.Now()},
+		"$unset": bson.M{"sla": ""},
+	}
+
+	if _, err := coll.UpdateOne(context.TODO(), filter, update); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CountByWorker returns the number of requests of the master by status code
+func (r RequestRepository) CountByWorker(workerId bson.ObjectID) (map[int]int64, error) {
+	coll := r.db.Database("TechPower").Collection("Requests")
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.M{"worker_id": workerId}}},
+		bson.D{{Key: "$group", Value: bson.M{"_id": "$status.code", "count": bson.M{"$sum": 1}}}},
+	}
+
+	cursor, err := coll.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var rows []struct {
+		Code  int   `bson:"_id"`
+		Count int64 `bson:"count"`
+	}
+	if err := cursor.All(context.TODO(), &rows); err != nil {
+		return nil, err
+	}
+
+	res := make(map[int]int64, len(rows))
+	for _, row := range rows {
+		res[row.Code] = row.Count
+	}
+	return res, nil
+}
+
+// GetByWorker returns active requests (status 2, 3 or 5) of the master with datetime in [from, to)
+func (r RequestRepository) GetByWorker(workerId bson.ObjectID, from time.Time, to time.Time, requests *[]models.Request) error {
+	coll := r.db.Database("TechPower").Collection("Requests")
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.M{
+			"worker_id":   workerId,
+			"status.code": bson.M{"$in": bson.A{2, 3, 5}},
+			"datetime":    bson.M{"$gte": from, "$lt": to},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.M{"datetime": 1}}},
+		bson.D{{Key: "$lookup", Value: bson.M{
+			"from":         "Category",
+			"localField":   "category_id",
+			"foreignField": "_id",
+			"as":           "category",
+		}}},
+		bson.D{{Key: "$unwind", Value: bson.M{"path": "$category", "preserveNullAndEmptyArrays": true}}},
+	}
+
+	cursor, err := coll.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.TODO())
+
+	if err := cursor.All(context.TODO(), requests); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r RequestRepository) SetLocation(id bson.ObjectID, location *models.Point) error {
+	coll := r.db.Database("TechPower").Collection("Requests")
+	filter := bson.M{"_id": id}
+	update := bson.M{"$set": bson.M{"location": location}}
+
+	res, err := coll.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return fmt.Errorf("Failed to change location of request")
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("Request not found")
+	}
+	return nil
+}
+
+// CheckIn records the arrival of the master, a visit has a single check-in
+func (r RequestRepository) CheckIn(id bson.ObjectID, event *models.VisitEvent) error {
+	coll := r.db.Database("TechPower").Collection("Requests")
+	filter := bson.M{"_id": id, "visit.check_in": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"visit.check_in": event, "in_spot": true}}
+
+	res, err := coll.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return fmt.Errorf("Failed to check in")
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("Master has already checked in")
+	}
+	return nil
+}
+
+func (r RequestRepository) CheckOut(id bson.ObjectID, event *models.VisitEvent, minutes int) error {
+	coll := r.db.Database("TechPower").Collection("Requests")
+	filter := bson.M{
+		"_id":             id,
+		"visit.check_in":  bson.M{"$exists": true},
+		"visit.check_out": bson.M{"$exists": false},
+	}
+	update := bson.M{"$set": bson.M{
+		"visit.check_out":       event,
+		"visit.on_site_minutes": minutes,
+		"in_spot":               false,
+	}}
+
+	res, err := coll.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return fmt.Errorf("Failed to check out")
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("Master has not checked in or has already checked out")
+	}
+	return nil
+}
+
+// GetUnflagged returns requests in the statuses that have no SLA breach yet, without lookups
+func (r RequestRepository) GetUnflagged(codes []int, requests *[]models.Request) error {
+	coll := r.db.Database("TechPower").Collection("Requests")
+	filter := bson.M{"status.code": bson.M{"$in": codes}, "sla": bson.M{"$exists": false}}
+
+	cursor, err := coll.Find(context.TODO(), filter)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.TODO())
+
+	if err := cursor.All(context.TODO(), requests); err != nil {
+		return err
+	}
+	return nil
+}
+
+// FlagSla marks the breach unless the status has changed in the meantime
+func (r RequestRepository) FlagSla(id bson.ObjectID, breach *models.SlaBreach) error {
+	coll := r.db.Database("TechPower").Collection("Requests")
+	filter := bson.M{"_id": id, "status.code": breach.Status, "sla": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"sla": breach}}
+
+	res, err := coll.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return fmt.Errorf("Failed to flag request")
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("Request status has changed")
+	}
+	return nil
+}
+
+func (r RequestRepository) ChangeDateTime(id bson.ObjectID, datetime time.Time) error {
+	coll := r.db.Database("TechPower").Collection("Requests")
+	filter := bson.M{"_id": id}
+	update := bson.M{"$set": bson.M{"datetime": datetime}}
+
+	res, err := coll.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return fmt.Errorf("Failed to change datetime of request")
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("Request not found")
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"techwizBackend/pkg/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type (
+	IReviewRepository interface {
+		Create(review *models.Review) error
+		GetByRequest(requestId bson.ObjectID, review *models.Review) error
+		GetByMaster(masterId bson.ObjectID, reviews *[]models.Review) error
+		RatingOf(masterId bson.ObjectID) (float64, int, error)
+	}
+
+	ReviewRepository struct {
+		db *mongo.Client
+	}
+)
+
+func NewReviewRepository(db *mongo.Client) *ReviewRepository {
+	return &ReviewRepository{db: db}
+}
+
+func (r ReviewRepository) Create(review *models.Review) error {
+	coll := r.db.Database("TechPower").Collection("Reviews")
+	res, err := coll.InsertOne(context.TODO(), review)
+	if err != nil {
+		// request_id is a unique index
+		if mongo.IsDuplicateKeyError(err) {
+			return errors.New("Review already exists")
+		}
+		return errors.New("Failed to create review")
+	}
+	review.Id = res.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r ReviewRepository) GetByRequest(requestId bson.ObjectID, review *models.Review) error {
+	coll := r.db.Database("TechPower").Collection("Reviews")
+	if err := coll.FindOne(context.TODO(), bson.M{"request_id": requestId}).Decode(review); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errors.New("Review not found")
+		}
+		return err
+	}
+	return nil
+}
+
+func (r ReviewRepository) GetByMaster(masterId bson.ObjectID, reviews *[]models.Review) error {
+	coll := r.db.Database("TechPower").Collection("Reviews")
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := coll.Find(context.TODO(), bson.M{"master_id": masterId}, opts)
+	if err != nil {
+		return errors.New("Reviews not found")
+	}
+	defer cursor.Close(context.TODO())
+
+	if err = cursor.All(context.TODO(), reviews); err != nil {
+		return errors.New("Failed to get reviews")
+	}
+	return nil
+}
+
+// RatingOf returns the average rating and the number of reviews of the master
+func (r ReviewRepository) RatingOf(masterId bson.ObjectID) (float64, int, error) {
+	coll := r.db.Database("TechPower").Collection("Reviews")
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.M{"master_id": masterId}}},
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id":    nil,
+			"rating": bson.M{"$avg": "$rating"},
+			"count":  bson.M{"$sum": 1},
+		}}},
+	}
+
+	cursor, err := coll.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var res struct {
+		Rating float64 `bson:"rating"`
+		Count  int     `bson:"count"`
+	}
+	if cursor.Next(context.TODO()) {
+		if err := cursor.Decode(&res); err != nil {
+			return 0, 0, err
+		}
+	}
+	return res.Rating, res.Count, nil
+}
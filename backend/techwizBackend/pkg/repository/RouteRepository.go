@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"techwizBackend/pkg/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type (
+	IRouteRepository interface {
+		Save(route *models.Route) error
+		Get(masterId bson.ObjectID, date string, route *models.Route) error
+	}
+
+	RouteRepository struct {
+		db *mongo.Client
+	}
+)
+
+func NewRouteRepository(db *mongo.Client) *RouteRepository {
+	return &RouteRepository{db: db}
+}
+
+// Save replaces the route of the master for the day
+func (r RouteRepository) Save(route *models.Route) error {
+	coll := r.db.Database("TechPower").Collection("Routes")
+	filter := bson.M{"master_id": route.MasterId, "date": route.Date}
+	update := bson.M{"$set": bson.M{
+		"start":          route.Start,
+		"stops":          route.Stops,
+		"distance":       route.Distance,
+		"travel_minutes": route.TravelMinutes,
+		"warnings":       route.Warnings,
+		"updated_at":     route.UpdatedAt,
+	}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	if err := coll.FindOneAndUpdate(context.TODO(), filter, update, opts).Decode(route); err != nil {
+		return errors.New("Failed to save route")
+	}
+	return nil
+}
+
+func (r RouteRepository) Get(masterId bson.ObjectID, date string, route *models.Route) error {
+	coll := r.db.Database("TechPower").Collection("Routes")
+	filter := bson.M{"master_id": masterId, "date": date}
+
+	if err := coll.FindOne(context.TODO(), filter).Decode(route); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errors.New("Route not found")
+		}
+		return err
+	}
+	return nil
+}
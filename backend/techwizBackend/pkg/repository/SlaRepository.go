@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"techwizBackend/pkg/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type (
+	ISlaRepository interface {
+		Create(policy *models.SlaPolicy) error
+		Get(policies *[]models.SlaPolicy) error
+		Remove(id bson.ObjectID) error
+	}
+
+	SlaRepository struct {
+		db *mongo.Client
+	}
+)
+
+func NewSlaRepository(db *mongo.Client) *SlaRepository {
+	return &SlaRepository{db: db}
+}
+
+func (r SlaRepository) Create(policy *models.SlaPolicy) error {
+	coll := r.db.Database("TechPower").Collection("SlaPolicies")
+	res, err := coll.InsertOne(context.TODO(), policy)
+	if err != nil {
+		return errors.New("Failed to create SLA policy")
+	}
+
+	id := res.InsertedID.(bson.ObjectID)
+	policy.Id = &id
+	return nil
+}
+
+func (r SlaRepository) Get(policies *[]models.SlaPolicy) error {
+	coll := r.db.Database("TechPower").Collection("SlaPolicies")
+	cursor, err := coll.Find(context.TODO(), bson.M{})
+	if err != nil {
+		return errors.New("SLA policies not found")
+	}
+	defer cursor.Close(context.TODO())
+
+	if err = cursor.All(context.TODO(), policies); err != nil {
+		return errors.New("Failed to get SLA policies")
+	}
+	return nil
+}
+
+func (r SlaRepository) Remove(id bson.ObjectID) error {
+	coll := r.db.Database("TechPower").Collection("SlaPolicies")
+	res, err := coll.DeleteOne(context.TODO(), bson.M{"_id": id})
+	if err != nil {
+		return errors.New("Failed to remove SLA policy")
+	}
+	if res.DeletedCount == 0 {
+		return errors.New("SLA policy not found")
+	}
+	return nil
+}
@@ -46,6 +46,19 @@ func (r StatisticRepository) Get() (*models.Statistics, error) {
 				{"preserveNullAndEmptyArrays", true},
 			}},
 		},
+		// Заказ без позиций считается одной позицией работы по цене заказа
+		bson.M{"$addFields": bson.M{
+			"lines": bson.M{"$cond": bson.M{
+				"if":   bson.M{"$gt": bson.A{bson.M{"$size": bson.M{"$ifNull": bson.A{"$items", bson.A{}}}}, 0}},
+				"then": "$items",
+				"else": bson.A{bson.M{
+					"type":       models.LineLabour,
+					"quantity":   1,
+					"unit_price": "$price",
+					"commission": "$commission",
+				}},
+			}},
+		}},
 		// Разделяем на фасеты
 		bson.D{{
 			"$facet",
@@ -60,21 +73,15 @@ func (r StatisticRepository) Get() (*models.Statistics, error) {
 					"total_commissions",
 					bson.A{
 						bson.D{{"$match", bson.D{{"status.code", 4}}}}, // только завершённые заказы
-						bson.D{{
-							"$project", bson.D{
-								{"commission", bson.D{
-									{"$multiply", bson.A{
-										"$price",
-										"$commission", // ← теперь берём комиссию напрямую из заказа
-									}},
-								}},
-							},
-						}},
-						bson.D{{
-							"$group", bson.D{
-								{"_id", nil},
-								{"total", bson.D{{"$sum", "$commission"}}},
-							},
+						bson.M{"$unwind": "$lines"},
+						// комиссия берётся из каждой позиции заказа
+						bson.M{"$group": bson.M{
+							"_id": nil,
+							"total": bson.M{"$sum": bson.M{"$multiply": bson.A{
+								"$lines.quantity",
+								"$lines.unit_price",
+								"$lines.commission",
+							}}},
 						}},
 					},
 				},
@@ -101,12 +108,32 @@ func (r StatisticRepository) Get() (*models.Statistics, error) {
 								bson.D{{"$eq", 4}}, // <-- Исправлено с 200 на 4
 							}},
 						}},
-						bson.D{{
-							"$group",
-							bson.D{
-								{"_id", nil},
-								{"total", bson.D{{"$sum", "$price"}}},
-							},
+						bson.M{"$unwind": "$lines"},
+						bson.M{"$group": bson.M{
+							"_id":   nil,
+							"total": bson.M{"$sum": bson.M{"$multiply": bson.A{"$lines.quantity", "$lines.unit_price"}}},
+						}},
+					},
+				},
+				{
+					"revenue_by_line_type",
+					bson.A{
+						bson.M{"$match": bson.M{"status.code": 4}},
+						bson.M{"$unwind": "$lines"},
+						bson.M{"$group": bson.M{
+							"_id":   "$lines.type",
+							"total": bson.M{"$sum": bson.M{"$multiply": bson.A{"$lines.quantity", "$lines.unit_price"}}},
+						}},
+					},
+				},
+				{
+					"time_on_site",
+					bson.A{
+						bson.M{"$match": bson.M{"visit.on_site_minutes": bson.M{"$exists": true}}},
+						bson.M{"$group": bson.M{
+							"_id":   nil,
+							"avg":   bson.M{"$avg": "$visit.on_site_minutes"},
+							"total": bson.M{"$sum": "$visit.on_site_minutes"},
 						}},
 					},
 				},
@@ -141,6 +168,24 @@ func (r StatisticRepository) Get() (*models.Statistics, error) {
 						}},
 					},
 				},
+				{
+					"orders_by_source",
+					bson.A{
+						bson.M{"$lookup": bson.M{
+							"from":         "ApiKeys",
+							"localField":   "source_id",
+							"foreignField": "_id",
+							"as":           "source",
+						}},
+						bson.M{"$group": bson.M{
+							"_id": bson.M{"$ifNull": bson.A{
+								bson.M{"$arrayElemAt": bson.A{"$source.name", 0}},
+								"direct",
+							}},
+							"count": bson.M{"$sum": 1},
+						}},
+					},
+				},
 				{
 					"orders_by_category",
 					bson.A{
@@ -195,6 +240,13 @@ func (r StatisticRepository) Get() (*models.Statistics, error) {
 						bson.A{"$total_revenue.total", 0},
 					}}, 0},
 				}}},
+				{"revenue_by_line_type", bson.M{"$arrayToObject": bson.M{"$map": bson.M{
+					"input": "$revenue_by_line_type",
+					"as":    "line",
+					"in":    bson.M{"k": "$$line._id", "v": "$$line.total"},
+				}}}},
+				{"avg_on_site_minutes", bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$time_on_site.avg", 0}}, 0}}},
+				{"total_on_site_minutes", bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$time_on_site.total", 0}}, 0}}},
 				{"orders_by_city", bson.D{{
 					"$arrayToObject",
 					bson.D{{
@@ -209,6 +261,11 @@ func (r StatisticRepository) Get() (*models.Statistics, error) {
 						},
 					}},
 				}}},
+				{"orders_by_source", bson.M{"$arrayToObject": bson.M{"$map": bson.M{
+					"input": "$orders_by_source",
+					"as":    "source",
+					"in":    bson.M{"k": "$$source._id", "v": "$$source.count"},
+				}}}},
 				{"orders_by_category", bson.D{{
 					"$arrayToObject",
 					bson.D{{
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"techwizBackend/pkg/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type (
+	ITierRepository interface {
+		Create(decision *models.TierDecision) error
+		GetById(id bson.ObjectID, decision *models.TierDecision) error
+		GetPending(masterId bson.ObjectID, decision *models.TierDecision) error
+		Get(filter bson.M, decisions *[]models.TierDecision) error
+		Decide(decision *models.TierDecision) error
+		Supersede(masterId bson.ObjectID) error
+	}
+
+	TierRepository struct {
+		db *mongo.Client
+	}
+)
+
+func NewTierRepository(db *mongo.Client) *TierRepository {
+	return &TierRepository{db: db}
+}
+
+func (r TierRepository) Create(decision *models.TierDecision) error {
+	coll := r.db.Database("TechPower").Collection("TierDecisions")
+	res, err := coll.InsertOne(context.TODO(), decision)
+	if err != nil {
+		return errors.New("Failed to save tier decision")
+	}
+	decision.Id = res.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r TierRepository) GetById(id bson.ObjectID, decision *models.TierDecision) error {
+	coll := r.db.Database("TechPower").Collection("TierDecisions")
+	if err := coll.FindOne(context.TODO(), bson.M{"_id": id}).Decode(decision); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errors.New("Tier decision not found")
+		}
+		return err
+	}
+	return nil
+}
+
+func (r TierRepository) GetPending(masterId bson.ObjectID, decision *models.TierDecision) error {
+	coll := r.db.Database("TechPower").Collection("TierDecisions")
+	filter := bson.M{"master_id": masterId, "status": models.TierProposed}
+	if err := coll.FindOne(context.TODO(), filter).Decode(decision); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errors.New("Tier decision not found")
+		}
+		return err
+	}
+	return nil
+}
+
+func (r TierRepository) Get(filter bson.M, decisions *[]models.TierDecision) error {
+	coll := r.db.Database("TechPower").Collection("TierDecisions")
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := coll.Find(context.TODO(), filter, opts)
+	if err != nil {
+		return errors.New("Tier decisions not found")
+	}
+	defer cursor.Close(context.TODO())
+
+	if err = cursor.All(context.TODO(), decisions); err != nil {
+		return errors.New("Failed to get tier decisions")
+	}
+	return nil
+}
+
+func (r TierRepository) Decide(decision *models.TierDecision) error {
+	coll := r.db.Database("TechPower").Collection("TierDecisions")
+	filter := bson.M{"_id": decision.Id, "status": models.TierProposed}
+	update := bson.M{"$set": bson.M{
+		"status":     decision.Status,
+		"decided_by": decision.DecidedBy,
+		"decided_at": decision.DecidedAt,
+	}}
+
+	res, err := coll.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return errors.New("Failed to update tier decision")
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("Tier decision is already decided")
+	}
+	return nil
+}
+
+// Supersede marks proposals of the master as replaced
+func (r TierRepository) Supersede(masterId bson.ObjectID) error {
+	coll := r.db.Database("TechPower").Collection("TierDecisions")
+	filter := bson.M{"master_id": masterId, "status": models.TierProposed}
+	update := bson.M{"$set": bson.M{"status": models.TierSuperseded}}
+
+	if _, err := coll.UpdateMany(context.TODO(), filter, update); err != nil {
+		return errors.New("Failed to supersede tier decisions")
+	}
+	return nil
+}
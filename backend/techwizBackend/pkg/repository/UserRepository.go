@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 	"techwizBackend/pkg/models"
+	"time"
 )
 
 type (
@@ -23,6 +25,13 @@ type (
 		RemoveStatus(id bson.ObjectID) error
 		DismissUserById(id bson.ObjectID) error
 		UpdateUser(idUser bson.ObjectID, user *models.User) error
+		SetRating(id bson.ObjectID, rating float64, count int) error
+		SetTierLocked(id bson.ObjectID, locked bool) error
+		SetBase(id bson.ObjectID, base *models.Point) error
+		GetNearestMasters(point *models.Point, categoryId *bson.ObjectID, limit int, masters *[]models.NearbyMaster) error
+		SetSchedule(id bson.ObjectID, schedule *models.Schedule) error
+		SetLastSeen(id bson.ObjectID, at time.Time) error
+		GetLastSeen(ids []bson.ObjectID) (map[bson.ObjectID]time.Time, error)
 	}
 
 	UserRepository struct {
@@ -411,3 +420,133 @@ func (r UserRepository) UpdateUser(idUser bson.ObjectID, user *models.User) erro
 	return nil
 
 }
+
+func (r UserRepository) SetRating(id bson.ObjectID, rating float64, count int) error {
+	coll := r.db.Database("TechPower").Collection("Users")
+	filter := bson.M{"_id": id}
+	update := bson.M{"$set": bson.M{"rating": rating, "rating_count": count}}
+
+	if _, err := coll.UpdateOne(context.TODO(), filter, update); err != nil {
+		return errors.New("Failed to update rating of master")
+	}
+
+	return nil
+}
+
+func (r UserRepository) SetTierLocked(id bson.ObjectID, locked bool) error {
+	coll := r.db.Database("TechPower").Collection("Users")
+	filter := bson.M{"_id": id}
+	update := bson.M{"$set": bson.M{"tier_locked": locked}}
+
+	if _, err := coll.UpdateOne(context.TODO(), filter, update); err != nil {
+		return errors.New("Failed to change tier lock of master")
+	}
+
+	return nil
+}
+
+func (r UserRepository) SetSchedule(id bson.ObjectID, schedule *models.Schedule) error {
+	coll := r.db.Database("TechPower").Collection("Users")
+	filter := bson.M{"_id": id}
+	update := bson.M{"$set": bson.M{"schedule": schedule}}
+
+	if _, err := coll.UpdateOne(context.TODO(), filter, update); err != nil {
+		return errors.New("Failed to update schedule of master")
+	}
+
+	return nil
+}
+
+func (r UserRepository) SetBase(id bson.ObjectID, base *models.Point) error {
+	coll := r.db.Database("TechPower").Collection("Users")
+	filter := bson.M{"_id": id}
+	update := bson.M{"$set": bson.M{"base": base}}
+
+	res, err := coll.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return errors.New("Failed to change base of master")
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("User not found")
+	}
+	return nil
+}
+
+// GetNearestMasters returns active masters of the category ordered by the distance from the point,
+// masters without a base are skipped
+func (r UserRepository) GetNearestMasters(point *models.Point, categoryId *bson.ObjectID, limit int, masters *[]models.NearbyMaster) error {
+	coll := r.db.Database("TechPower").Collection("Users")
+	pipeline := mongo.Pipeline{
+		// $geoNear must be the first stage and uses the 2dsphere index of base
+		bson.D{{Key: "$geoNear", Value: bson.M{
+			"near":          point,
+			"key":           "base",
+			"distanceField": "distance",
+			"spherical":     true,
+			"query":         nearestMastersQuery(categoryId),
+		}}},
+		bson.D{{Key: "$limit", Value: limit}},
+		bson.D{{Key: "$lookup", Value: bson.M{
+			"from":         "Category",
+			"localField":   "categories_id",
+			"foreignField": "_id",
+			"as":           "categories",
+		}}},
+		bson.D{{Key: "$unset", Value: bson.A{"categories_id", "password"}}},
+	}
+
+	cursor, err := coll.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return fmt.Errorf("failed to find masters: %w", err)
+	}
+	defer cursor.Close(context.TODO())
+
+	if err = cursor.All(context.TODO(), masters); err != nil {
+		return errors.New("Failed to get masters")
+	}
+	return nil
+}
+
+// nearestMastersQuery selects the masters that can take the request:
+// users with a master status that are not dismissed, of the category if it is set
+func nearestMastersQuery(categoryId *bson.ObjectID) bson.M {
+	query := bson.M{
+		"status":    bson.M{"$exists": true},
+		"dismissed": bson.M{"$ne": true},
+	}
+	if categoryId != nil {
+		query["categories_id"] = *categoryId
+	}
+	return query
+}
+
+func (r UserRepository) SetLastSeen(id bson.ObjectID, at time.Time) error {
+	coll := r.db.Database("TechPower").Collection("Users")
+	if _, err := coll.UpdateOne(context.TODO(), bson.M{"_id": id}, bson.M{"$set": bson.M{"last_seen": at}}); err != nil {
+		return errors.New("Failed to update last seen")
+	}
+	return nil
+}
+
+func (r UserRepository) GetLastSeen(ids []bson.ObjectID) (map[bson.ObjectID]time.Time, error) {
+	coll := r.db.Database("TechPower").Collection("Users")
+	filter := bson.M{"_id": bson.M{"$in": ids}, "last_seen": bson.M{"$exists": true}}
+	opts := options.Find().SetProjection(bson.M{"last_seen": 1})
+
+	cursor, err := coll.Find(context.TODO(), filter, opts)
+	if err != nil {
+		return nil, errors.New("Failed to get last seen")
+	}
+	defer cursor.Close(context.TODO())
+
+	var users []models.User
+	if err = cursor.All(context.TODO(), &users); err != nil {
+		return nil, errors.New("Failed to get last seen")
+	}
+
+	lastSeen := make(map[bson.ObjectID]time.Time, len(users))
+	for _, user := range users {
+		lastSeen[user.Id] = *user.LastSeen
+	}
+	return lastSeen, nil
+}
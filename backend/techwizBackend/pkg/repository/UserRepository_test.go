@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestNearestMastersQuery(t *testing.T) {
+	categoryId := bson.NewObjectID()
+
+	tests := []struct {
+		name       string
+		categoryId *bson.ObjectID
+		want       bson.M
+	}{
+		{
+			name: "any category",
+			want: bson.M{
+				"status":    bson.M{"$exists": true},
+				"dismissed": bson.M{"$ne": true},
+			},
+		},
+		{
+			name:       "category of the request",
+			categoryId: &categoryId,
+			want: bson.M{
+				"status":        bson.M{"$exists": true},
+				"dismissed":     bson.M{"$ne": true},
+				"categories_id": categoryId,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nearestMastersQuery(tt.categoryId); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("nearestMastersQuery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"techwizBackend/pkg/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type (
+	IWebhookRepository interface {
+		Create(webhook *models.Webhook) error
+		Get(webhooks *[]models.Webhook) error
+		GetById(id bson.ObjectID, webhook *models.Webhook) error
+		GetActive(webhooks *[]models.Webhook) error
+		Remove(id bson.ObjectID) error
+		CreateDelivery(delivery *models.WebhookDelivery) error
+		GetDelivery(id bson.ObjectID, delivery *models.WebhookDelivery) error
+		GetDeliveries(filter bson.M, deliveries *[]models.WebhookDelivery) error
+		AddAttempt(id bson.ObjectID, attempt models.DeliveryAttempt, status string) error
+		ResetDelivery(id bson.ObjectID) error
+	}
+
+	WebhookRepository struct {
+		db *mongo.Client
+	}
+)
+
+func NewWebhookRepository(db *mongo.Client) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r WebhookRepository) Create(webhook *models.Webhook) error {
+	coll := r.db.Database("TechPower").Collection("Webhooks")
+	res, err := coll.InsertOne(context.TODO(), webhook)
+	if err != nil {
+		return errors.New("Failed to create webhook")
+	}
+	webhook.Id = res.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+// Get returns webhooks without secrets
+func (r WebhookRepository) Get(webhooks *[]models.Webhook) error {
+	coll := r.db.Database("TechPower").Collection("Webhooks")
+	opts := options.Find().SetProjection(bson.M{"secret": 0})
+
+	cursor, err := coll.Find(context.TODO(), bson.M{}, opts)
+	if err != nil {
+		return errors.New("Webhooks not found")
+	}
+	defer cursor.Close(context.TODO())
+
+	if err = cursor.All(context.TODO(), webhooks); err != nil {
+		return errors.New("Failed to get webhooks")
+	}
+	return nil
+}
+
+func (r WebhookRepository) GetById(id bson.ObjectID, webhook *models.Webhook) error {
+	coll := r.db.Database("TechPower").Collection("Webhooks")
+
+	if err := coll.FindOne(context.TODO(), bson.M{"_id": id}).Decode(webhook); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errors.New("Webhook not found")
+		}
+		return err
+	}
+	return nil
+}
+
+func (r WebhookRepository) GetActive(webhooks *[]models.Webhook) error {
+	coll := r.db.Database("TechPower").Collection("Webhooks")
+
+	cursor, err := coll.Find(context.TODO(), bson.M{"active": true})
+	if err != nil {
+		return errors.New("Webhooks not found")
+	}
+	defer cursor.Close(context.TODO())
+
+	if err = cursor.All(context.TODO(), webhooks); err != nil {
+		return errors.New("Failed to get webhooks")
+	}
+	return nil
+}
+
+func (r WebhookRepository) Remove(id bson.ObjectID) error {
+	coll := r.db.Database("TechPower").Collection("Webhooks")
+	res, err := coll.DeleteOne(context.TODO(), bson.M{"_id": id})
+	if err != nil {
+		return errors.New("Failed to remove webhook")
+	}
+	if res.DeletedCount == 0 {
+		return errors.New("Webhook not found")
+	}
+	return nil
+}
+
+func (r WebhookRepository) CreateDelivery(delivery *models.WebhookDelivery) error {
+	coll := r.db.Database("TechPower").Collection("WebhookDeliveries")
+	res, err := coll.InsertOne(context.TODO(), delivery)
+	if err != nil {
+		return errors.New("Failed to create delivery")
+	}
+	delivery.Id = res.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r WebhookRepository) GetDelivery(id bson.ObjectID, delivery *models.WebhookDelivery) error {
+	coll := r.db.Database("TechPower").Collection("WebhookDeliveries")
+
+	if err := coll.FindOne(context.TODO(), bson.M{"_id": id}).Decode(delivery); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errors.New("Delivery not found")
+		}
+		return err
+	}
+	return nil
+}
+
+func (r WebhookRepository) GetDeliveries(filter bson.M, deliveries *[]models.WebhookDelivery) error {
+	coll := r.db.Database("TechPower").Collection("WebhookDeliveries")
+	opts := options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(200)
+
+	cursor, err := coll.Find(context.TODO(), filter, opts)
+	if err != nil {
+		return errors.New("Deliveries not found")
+	}
+	defer cursor.Close(context.TODO())
+
+	if err = cursor.All(context.TODO(), deliveries); err != nil {
+		return errors.New("Failed to get deliveries")
+	}
+	return nil
+}
+
+// AddAttempt logs the attempt and sets the status of the delivery
+func (r WebhookRepository) AddAttempt(id bson.ObjectID, attempt models.DeliveryAttempt, status string) error {
+	coll := r.db.Database("TechPower").Collection("WebhookDeliveries")
+	set := bson.M{"status": status}
+	if status == models.DeliveryDelivered {
+		set["delivered_at"] = attempt.At
+	}
+	update := bson.M{"$set": set, "$push": bson.M{"attempts": attempt}}
+
+	if _, err := coll.UpdateOne(context.TODO(), bson.M{"_id": id}, update); err != nil {
+		return errors.New("Failed to log delivery attempt")
+	}
+	return nil
+}
+
+// ResetDelivery makes the delivery pending again for a replay, the log is kept
+func (r WebhookRepository) ResetDelivery(id bson.ObjectID) error {
+	coll := r.db.Database("TechPower").Collection("WebhookDeliveries")
+	update := bson.M{"$set": bson.M{"status": models.DeliveryPending, "replayed_at": time.Now()}}
+
+	res, err := coll.UpdateOne(context.TODO(), bson.M{"_id": id}, update)
+	if err != nil {
+		return errors.New("Failed to replay delivery")
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("Delivery not found")
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"techwizBackend/pkg/models"
+	"techwizBackend/pkg/repository"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// checkAdmin allows the action only to the admin, action completes "Only the admin can ..."
+func checkAdmin(users repository.IUserRepository, userId bson.ObjectID, action string) (int, error) {
+	var user models.User
+	if err := users.GetUserById(userId, &user); err != nil {
+		return http.StatusNotFound, err
+	}
+	if user.Permission != models.Admin {
+		return http.StatusForbidden, errors.New("Only the admin can " + action)
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"techwizBackend/pkg/config"
+	"techwizBackend/pkg/models"
+	"techwizBackend/pkg/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const apiKeyPrefix = "tp_"
+
+type (
+	IApiKeyService interface {
+		Create(userId bson.ObjectID, key *models.ApiKey) (int, error)
+		GetKeys(userId bson.ObjectID) (int, *[]models.ApiKey, error)
+		Revoke(userId bson.ObjectID, id bson.ObjectID) (int, error)
+		Authenticate(raw string, scope string) (int, *models.ApiKey, error)
+	}
+
+	ApiKeyService struct {
+		ApiKeyRepository repository.IApiKeyRepository
+		UserRepository   repository.IUserRepository
+		Config           *config.Config
+
+		mu      sync.Mutex
+		windows map[bson.ObjectID]*rateWindow
+	}
+
+	// rateWindow counts the requests of a key in the current minute
+	rateWindow struct {
+		start time.Time
+		count int
+	}
+)
+
+func NewApiKeyService(
+	apiKeyRepository repository.IApiKeyRepository,
+	userRepository repository.IUserRepository,
+	cfg *config.Config,
+) *ApiKeyService {
+	return &ApiKeyService{
+		ApiKeyRepository: apiKeyRepository,
+		UserRepository:   userRepository,
+		Config:           cfg,
+		windows:          map[bson.ObjectID]*rateWindow{},
+	}
+}
+
+// Create issues the key, the raw key is returned only here
+func (s *ApiKeyService) Create(userId bson.ObjectID, key *models.ApiKey) (int, error) {
+	if status, err := checkAdmin(s.UserRepository, userId, "manage api keys"); err != nil {
+		return status, err
+	}
+
+	if strings.TrimSpace(key.Name) == "" {
+		return http.StatusBadRequest, errors.New("Name is required")
+	}
+	if len(key.Scopes) == 0 {
+		return http.StatusBadRequest, errors.New("At least one scope is required")
+	}
+	for _, scope := range key.Scopes {
+		if scope != models.ScopeRequestCreate && scope != models.ScopeRequestRead {
+			return http.StatusBadRequest, fmt.Errorf("Unknown scope %s", scope)
+		}
+	}
+	if key.RateLimit < 0 {
+		return http.StatusBadRequest, errors.New("Rate limit can't be negative")
+	}
+
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	raw := apiKeyPrefix + hex.EncodeToString(b)
+
+	key.Key = raw
+	key.Prefix = raw[:len(apiKeyPrefix)+6]
+	key.Hash = hashKey(raw)
+	key.Active = true
+	key.CreatedAt = time.Now()
+	key.LastUsedAt = nil
+
+	if err := s.ApiKeyRepository.Create(key); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusCreated, nil
+}
+
+func (s *ApiKeyService) GetKeys(userId bson.ObjectID) (int, *[]models.ApiKey, error) {
+	if status, err := checkAdmin(s.UserRepository, userId, "manage api keys"); err != nil {
+		return status, nil, err
+	}
+
+	keys := []models.ApiKey{}
+	if err := s.ApiKeyRepository.Get(&keys); err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	return http.StatusOK, &keys, nil
+}
+
+func (s *ApiKeyService) Revoke(userId bson.ObjectID, id bson.ObjectID) (int, error) {
+	if status, err := checkAdmin(s.UserRepository, userId, "manage api keys"); err != nil {
+		return status, err
+	}
+
+	if err := s.ApiKeyRepository.Revoke(id); err != nil {
+		return http.StatusNotFound, err
+	}
+	return http.StatusOK, nil
+}
+
+// Authenticate finds the active key with the scope and counts the request against its rate limit
+func (s *ApiKeyService) Authenticate(raw string, scope string) (int, *models.ApiKey, error) {
+	if raw == "" {
+		return http.StatusUnauthorized, nil, errors.New("Api key is required")
+	}
+
+	var key models.ApiKey
+	if err := s.ApiKeyRepository.GetByHash(hashKey(raw), &key); err != nil || !key.Active {
+		return http.StatusUnauthorized, nil, errors.New("Invalid api key")
+	}
+	if !key.HasScope(scope) {
+		return http.StatusForbidden, nil, fmt.Errorf("Api key has no scope %s", scope)
+	}
+
+	limit := key.RateLimit
+	if limit == 0 {
+		limit = s.Config.ApiKeyRateLimit
+	}
+	if !s.allow(key.Id, limit) {
+		return http.StatusTooManyRequests, nil, fmt.Errorf("Rate limit of %d requests per minute is exceeded", limit)
+	}
+
+	if err := s.ApiKeyRepository.Touch(key.Id); err != nil {
+		log.Println(err)
+	}
+	return http.StatusOK, &key, nil
+}
+
+// allow is a fixed window of a minute, the counters live in memory of the instance
+func (s *ApiKeyService) allow(id bson.ObjectID, limit int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	window, ok := s.windows[id]
+	if !ok || now.Sub(window.start) >= time.Minute {
+		window = &rateWindow{start: now}
+		s.windows[id] = window
+	}
+	if window.count >= limit {
+		return false
+	}
+	window.count++
+	return true
+}
+
+func hashKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
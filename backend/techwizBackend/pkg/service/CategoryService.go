@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"techwizBackend/pkg/models"
 	"techwizBackend/pkg/repository"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
 type (
@@ -13,6 +15,7 @@ type (
 		Rename(category *models.Category, status *int) error
 		Remove(category *models.Category) (int, error)
 		Get() *[]models.Category
+		SetDuration(id bson.ObjectID, minutes int) (int, error)
 	}
 
 	CategoryService struct {
@@ -123,3 +126,15 @@ func (s *CategoryService) Rename(category *models.Category, status *int) error {
 	*status = http.StatusOK
 	return nil
 }
+
+// SetDuration changes the estimated job duration of the category in minutes
+func (s *CategoryService) SetDuration(id bson.ObjectID, minutes int) (int, error) {
+	if minutes <= 0 {
+		return http.StatusBadRequest, errors.New("Duration must be positive")
+	}
+
+	if err := s.CategoryRepository.SetDuration(id, minutes); err != nil {
+		return http.StatusNotFound, err
+	}
+	return http.StatusOK, nil
+}
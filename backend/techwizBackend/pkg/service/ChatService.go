@@ -2,9 +2,12 @@ package service
 
 import (
 	"errors"
+	"log"
 	"net/http"
+	"slices"
 	"techwizBackend/pkg/models"
 	"techwizBackend/pkg/repository"
+	"time"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
@@ -17,24 +20,36 @@ type (
 		GetChats(userId bson.ObjectID) *[]models.Chat
 		AddUser(idUser bson.ObjectID, idChat bson.ObjectID) error
 		RemoveUser(idUser bson.ObjectID, idChat bson.ObjectID) error
+		MarkRead(idUser bson.ObjectID, idChat bson.ObjectID, messageId *bson.ObjectID) (int, *models.ReadReceipt, error)
+		SetPusher(pusher IPusher)
+		GetContacts(idUser bson.ObjectID) []bson.ObjectID
+		TypingRecipients(idUser bson.ObjectID, idChat bson.ObjectID) ([]bson.ObjectID, error)
 	}
 
 	ChatService struct {
-		ChatRepository repository.IChatRepository
-		UserRepository repository.IUserRepository
+		ChatRepository    repository.IChatRepository
+		UserRepository    repository.IUserRepository
+		MessageRepository repository.IMessageRepository
+		Pusher            IPusher
 	}
 )
 
 func NewChatService(
 	chatRepository repository.IChatRepository,
 	userRepository repository.IUserRepository,
+	messageRepository repository.IMessageRepository,
 ) IChatService {
 	return &ChatService{
-		ChatRepository: chatRepository,
-		UserRepository: userRepository,
+		ChatRepository:    chatRepository,
+		UserRepository:    userRepository,
+		MessageRepository: messageRepository,
 	}
 }
 
+func (s *ChatService) SetPusher(pusher IPusher) {
+	s.Pusher = pusher
+}
+
 func (s ChatService) Create(chat *models.Chat) (int, error) {
 	var tmp_chat models.Chat
 	if err := s.ChatRepository.GetChatByMembers(chat.MembersId[0], chat.MembersId[1], &tmp_chat); err == nil {
@@ -59,14 +74,100 @@ func (s ChatService) GetChatByMember(member1, member2 bson.ObjectID) *models.Cha
 	return &chat
 }
 
+// GetChats returns the chats of the user with the unread counters
 func (s ChatService) GetChats(userId bson.ObjectID) *[]models.Chat {
 	var chats []models.Chat
 	if err := s.ChatRepository.GetChats(userId, &chats); err != nil {
 		return &[]models.Chat{}
 	}
+
+	markers := make(map[bson.ObjectID]*bson.ObjectID, len(chats))
+	for _, chat := range chats {
+		if chat.Id == nil {
+			continue
+		}
+		markers[*chat.Id] = nil
+		if marker, ok := chat.ReadBy[userId.Hex()]; ok {
+			markers[*chat.Id] = &marker.MessageId
+		}
+	}
+	unread, err := s.MessageRepository.CountUnread(userId, markers)
+	if err != nil {
+		log.Println(err)
+	}
+
+	for i := range chats {
+		chat := &chats[i]
+		if chat.Id != nil {
+			chat.Unread = unread[*chat.Id]
+		}
+		if chat.IsGroup() {
+			chat.ReadBy = nil
+		}
+	}
 	return &chats
 }
 
+// MarkRead moves the read marker of the member, without messageId up to the last message.
+// The other members get the receipt, in group chats only the number of readers
+func (s ChatService) MarkRead(idUser bson.ObjectID, idChat bson.ObjectID, messageId *bson.ObjectID) (int, *models.ReadReceipt, error) {
+	var chat models.Chat
+	if err := s.ChatRepository.GetChatById(idChat, &chat); err != nil {
+		return http.StatusNotFound, nil, err
+	}
+	if !slices.Contains(chat.MembersId, idUser) {
+		return http.StatusForbidden, nil, errors.New("User is not a member of the chat")
+	}
+
+	var message models.Message
+	if messageId == nil {
+		if err := s.MessageRepository.GetLast(idChat, &message); err != nil {
+			return http.StatusNotFound, nil, err
+		}
+	} else {
+		if err := s.MessageRepository.GetById(*messageId, &message); err != nil {
+			return http.StatusNotFound, nil, err
+		}
+		if message.Chat != idChat {
+			return http.StatusBadRequest, nil, errors.New("Message is not in the chat")
+		}
+	}
+
+	marker := models.ReadMarker{MessageId: message.Id, At: time.Now()}
+	if err := s.ChatRepository.SetReadMarker(idChat, idUser, marker); err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	receipt := models.ReadReceipt{ChatId: idChat, MessageId: message.Id, At: marker.At}
+	if chat.IsGroup() {
+		// the marker of the member is not in the chat loaded before the update
+		chat.ReadBy = nil
+		if err := s.ChatRepository.GetChatById(idChat, &chat); err != nil {
+			return http.StatusInternalServerError, nil, err
+		}
+		count := 0
+		for _, item := range chat.ReadBy {
+			if item.MessageId.Hex() >= message.Id.Hex() {
+				count++
+			}
+		}
+		receipt.ReadCount = &count
+	} else {
+		receipt.UserId = &idUser
+	}
+
+	if s.Pusher != nil {
+		others := make([]bson.ObjectID, 0, len(chat.MembersId))
+		for _, member := range chat.MembersId {
+			if member != idUser {
+				others = append(others, member)
+			}
+		}
+		s.Pusher.Push(others, models.WsRead, receipt)
+	}
+	return http.StatusOK, &receipt, nil
+}
+
 func (s ChatService) AddUser(idUser bson.ObjectID, idChat bson.ObjectID) error {
 	var chat models.Chat
 	if err := s.ChatRepository.GetChatById(idChat, &chat); err != nil {
@@ -93,3 +194,32 @@ func (s ChatService) RemoveUser(idUser bson.ObjectID, idChat bson.ObjectID) erro
 
 	return nil
 }
+
+// GetContacts returns the users who share a chat with the user, they get his presence
+func (s ChatService) GetContacts(idUser bson.ObjectID) []bson.ObjectID {
+	contacts, err := s.ChatRepository.GetContacts(idUser)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	return contacts
+}
+
+// TypingRecipients returns the other members of the chat, the user must be a member
+func (s ChatService) TypingRecipients(idUser bson.ObjectID, idChat bson.ObjectID) ([]bson.ObjectID, error) {
+	var chat models.Chat
+	if err := s.ChatRepository.GetChatById(idChat, &chat); err != nil {
+		return nil, err
+	}
+	if !slices.Contains(chat.MembersId, idUser) {
+		return nil, errors.New("User is not a member of the chat")
+	}
+
+	others := make([]bson.ObjectID, 0, len(chat.MembersId))
+	for _, member := range chat.MembersId {
+		if member != idUser {
+			others = append(others, member)
+		}
+	}
+	return others, nil
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"techwizBackend/pkg/document"
+	"techwizBackend/pkg/models"
+	"techwizBackend/pkg/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type (
+	IDocumentService interface {
+		Generate(requestId bson.ObjectID) (int, error)
+		Get(requestId bson.ObjectID, kind string, doc *models.Document) (int, error)
+		GetByRequest(requestId bson.ObjectID) (int, *[]models.Document)
+		GetTemplate() *models.DocumentTemplate
+		UpdateTemplate(tmpl *models.DocumentTemplate) (int, error)
+	}
+
+	DocumentService struct {
+		DocumentRepository repository.IDocumentRepository
+		RequestRepository  repository.IRequestRepository
+	}
+)
+
+func NewDocumentService(
+	documentRepository repository.IDocumentRepository,
+	requestRepository repository.IRequestRepository,
+) *DocumentService {
+	return &DocumentService{
+		DocumentRepository: documentRepository,
+		RequestRepository:  requestRepository,
+	}
+}
+
+// Generate renders the invoice and the act of the completed request
+func (s DocumentService) Generate(requestId bson.ObjectID) (int, error) {
+	var request models.Request
+	if err := s.RequestRepository.GetRequest(requestId, &request); err != nil {
+		return http.StatusNotFound, err
+	}
+
+	if request.Status.Code != 4 {
+		return http.StatusBadRequest, errors.New("Documents are available only for completed requests")
+	}
+
+	tmpl := s.GetTemplate()
+	data := documentData(&request)
+	for _, kind := range []string{models.Invoice, models.Act} {
+		content, err := document.Render(kind, *tmpl, data)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		doc := models.Document{
+			RequestId: requestId,
+			Kind:      kind,
+			FileName:  fmt.Sprintf("%s_%s.pdf", kind, data.Number),
+			Content:   content,
+			CreatedAt: time.Now(),
+		}
+		if err := s.DocumentRepository.Save(&doc); err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
+
+	return http.StatusCreated, nil
+}
+
+func (s DocumentService) Get(requestId bson.ObjectID, kind string, doc *models.Document) (int, error) {
+	if kind != models.Invoice && kind != models.Act {
+		return http.StatusBadRequest, errors.New("Invalid document kind")
+	}
+
+	if err := s.DocumentRepository.Get(requestId, kind, doc); err != nil {
+		return http.StatusNotFound, err
+	}
+	return http.StatusOK, nil
+}
+
+func (s DocumentService) GetByRequest(requestId bson.ObjectID) (int, *[]models.Document) {
+	var documents []models.Document
+	if err := s.DocumentRepository.GetByRequest(requestId, &documents); err != nil {
+		return http.StatusBadRequest, &[]models.Document{}
+	}
+	return http.StatusOK, &documents
+}
+
+// GetTemplate returns the saved template or the default one
+func (s DocumentService) GetTemplate() *models.DocumentTemplate {
+	var tmpl models.DocumentTemplate
+	if err := s.DocumentRepository.GetTemplate(&tmpl); err != nil {
+		tmpl = document.DefaultTemplate()
+	}
+	return &tmpl
+}
+
+func (s DocumentService) UpdateTemplate(tmpl *models.DocumentTemplate) (int, error) {
+	def := document.DefaultTemplate()
+	if tmpl.CompanyName == "" {
+		tmpl.CompanyName = def.CompanyName
+	}
+	if tmpl.InvoiceTitle == "" {
+		tmpl.InvoiceTitle = def.InvoiceTitle
+	}
+	if tmpl.ActTitle == "" {
+		tmpl.ActTitle = def.ActTitle
+	}
+
+	if err := document.Validate(*tmpl); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	if err := s.DocumentRepository.SaveTemplate(tmpl); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func documentData(request *models.Request) models.DocumentData {
+	data := models.DocumentData{
+		Number:   strings.ToUpper(request.Id.Hex()[16:]),
+		Date:     time.Now().Format("02.01.2006"),
+		Customer: request.FullName,
+		Phone:    request.PhoneNumber,
+		Address:  request.Address,
+		Problem:  request.Problem,
+		Items:    request.Items,
+		Total:    request.Price,
+	}
+	if request.Category != nil {
+		data.Category = request.Category.Name
+	}
+	if request.Worker != nil {
+		data.Master = request.Worker.FullName
+	}
+	// request without items is a single line of labour
+	if len(data.Items) == 0 {
+		description := request.Problem
+		if description == "" {
+			description = "Выполненные работы"
+		}
+		data.Items = []models.LineItem{{
+			Type:        models.LineLabour,
+			Description: description,
+			Quantity:    1,
+			UnitPrice:   request.Price,
+		}}
+	}
+	return data
+}
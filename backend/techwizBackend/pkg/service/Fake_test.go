@@ -0,0 +1,158 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"techwizBackend/pkg/models"
+	"techwizBackend/pkg/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// fakeRequestRepository keeps the requests in memory,
+// methods that the tests do not use panic on the nil interface
+type fakeRequestRepository struct {
+	repository.IRequestRepository
+	requests map[bson.ObjectID]models.Request
+}
+
+func newFakeRequestRepository(requests ...models.Request) *fakeRequestRepository {
+	r := fakeRequestRepository{requests: map[bson.ObjectID]models.Request{}}
+	for _, request := range requests {
+		r.requests[request.Id] = request
+	}
+	return &r
+}
+
+func (r *fakeRequestRepository) Create(request *models.Request) error {
+	request.Id = bson.NewObjectID()
+	r.requests[request.Id] = *request
+	return nil
+}
+
+func (r *fakeRequestRepository) GetRequest(id bson.ObjectID, request *models.Request) error {
+	stored, ok := r.requests[id]
+	if !ok {
+		return errors.New("Request not found")
+	}
+	*request = stored
+	return nil
+}
+
+func (r *fakeRequestRepository) ChangeStatus(id bson.ObjectID, status *models.Request) error {
+	stored, ok := r.requests[id]
+	if !ok {
+		return errors.New("Request not found")
+	}
+	stored.Status = status.Status
+	r.requests[id] = stored
+	return nil
+}
+
+// fakeWebhookService records the emitted events
+type fakeWebhookService struct {
+	IWebhookService
+	events []string
+}
+
+func (s *fakeWebhookService) Emit(event string, request *models.Request) {
+	s.events = append(s.events, event)
+}
+
+// jobCall is a recorded Complete or Fail, at is the next run or the retry
+type jobCall struct {
+	id      bson.ObjectID
+	at      *time.Time
+	message string
+}
+
+// fakeJobRepository hands out the due jobs in order and records the results
+type fakeJobRepository struct {
+	repository.IJobRepository
+	due       []models.Job
+	skipTypes [][]string
+	completed []jobCall
+	failed    []jobCall
+}
+
+func (r *fakeJobRepository) Acquire(owner string, lease time.Duration, skipTypes []string, job *models.Job) error {
+	r.skipTypes = append(r.skipTypes, skipTypes)
+	if len(r.due) == 0 {
+		return mongo.ErrNoDocuments
+	}
+	*job = r.due[0]
+	r.due = r.due[1:]
+	return nil
+}
+
+func (r *fakeJobRepository) Complete(job *models.Job, next *time.Time, message string) error {
+	r.completed = append(r.completed, jobCall{id: job.Id, at: next, message: message})
+	return nil
+}
+
+func (r *fakeJobRepository) Fail(job *models.Job, message string, retryAt *time.Time) error {
+	r.failed = append(r.failed, jobCall{id: job.Id, at: retryAt, message: message})
+	return nil
+}
+
+// Ensure returns the stored job with the key, otherwise the job is stored
+func (r *fakeJobRepository) Ensure(job *models.Job) error {
+	for _, stored := range r.due {
+		if stored.Key == job.Key {
+			*job = stored
+			return nil
+		}
+	}
+	job.Id = bson.NewObjectID()
+	r.due = append(r.due, *job)
+	return nil
+}
+
+func (r *fakeJobRepository) SetCron(id bson.ObjectID, cron string, runAt time.Time) error {
+	for i := range r.due {
+		if r.due[i].Id == id {
+			r.due[i].Cron = cron
+			r.due[i].RunAt = runAt
+			return nil
+		}
+	}
+	return errors.New("Job not found")
+}
+
+// fakeJobService keeps the keyed jobs, a job with the same key is moved as in Enqueue
+type fakeJobService struct {
+	IJobService
+	jobs map[string]models.Job
+}
+
+func newFakeJobService() *fakeJobService {
+	return &fakeJobService{jobs: map[string]models.Job{}}
+}
+
+func (s *fakeJobService) Enqueue(jobType string, key string, payload map[string]any, runAt time.Time) (*models.Job, error) {
+	job := models.Job{Type: jobType, Key: key, Payload: payload, Status: models.JobPending, RunAt: runAt}
+	s.jobs[key] = job
+	return &job, nil
+}
+
+func (s *fakeJobService) Unschedule(key string) error {
+	delete(s.jobs, key)
+	return nil
+}
+
+// the side effects of a status change that the tests do not check
+type (
+	fakeRouteService        struct{ IRouteService }
+	fakeNotificationService struct{ INotificationService }
+	fakeDocumentService     struct{ IDocumentService }
+)
+
+func (fakeRouteService) Replan(*models.Request) {}
+
+func (fakeNotificationService) Notify([]bson.ObjectID, models.Notification) {}
+
+func (fakeDocumentService) Generate(bson.ObjectID) (int, error) {
+	return http.StatusOK, nil
+}
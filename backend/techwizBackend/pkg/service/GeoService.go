@@ -0,0 +1,118 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"techwizBackend/pkg/geo"
+	"techwizBackend/pkg/models"
+	"techwizBackend/pkg/repository"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type (
+	IGeoService interface {
+		Geocode(address string) (*models.Point, error)
+		LocateRequest(id bson.ObjectID, location *models.Point) (int, *models.Point, error)
+		SetBase(masterId bson.ObjectID, address string, base *models.Point) (int, *models.Point, error)
+		NearestMasters(requestId bson.ObjectID, limit int) (int, *[]models.NearbyMaster, error)
+	}
+
+	GeoService struct {
+		RequestRepository repository.IRequestRepository
+		UserRepository    repository.IUserRepository
+		Geocoder          geo.Geocoder
+	}
+)
+
+func NewGeoService(
+	requestRepository repository.IRequestRepository,
+	userRepository repository.IUserRepository,
+	geocoder geo.Geocoder,
+) *GeoService {
+	return &GeoService{
+		RequestRepository: requestRepository,
+		UserRepository:    userRepository,
+		Geocoder:          geocoder,
+	}
+}
+
+func (s GeoService) Geocode(address string) (*models.Point, error) {
+	if address == "" {
+		return nil, errors.New("Address is empty")
+	}
+	return s.Geocoder.Geocode(address)
+}
+
+// LocateRequest sets the location by hand, without location the address of the request is geocoded
+func (s GeoService) LocateRequest(id bson.ObjectID, location *models.Point) (int, *models.Point, error) {
+	if location == nil {
+		var request models.Request
+		if err := s.RequestRepository.GetRequest(id, &request); err != nil {
+			return http.StatusNotFound, nil, err
+		}
+
+		point, err := s.Geocode(request.Address)
+		if err != nil {
+			return http.StatusUnprocessableEntity, nil, err
+		}
+		location = point
+	}
+
+	if !location.IsValid() {
+		return http.StatusBadRequest, nil, errors.New("Invalid location")
+	}
+
+	if err := s.RequestRepository.SetLocation(id, location); err != nil {
+		return http.StatusNotFound, nil, err
+	}
+	return http.StatusOK, location, nil
+}
+
+// SetBase sets the home base of the master by coordinates or by the geocoded address
+func (s GeoService) SetBase(masterId bson.ObjectID, address string, base *models.Point) (int, *models.Point, error) {
+	if base == nil {
+		point, err := s.Geocode(address)
+		if err != nil {
+			return http.StatusUnprocessableEntity, nil, err
+		}
+		base = point
+	}
+
+	if !base.IsValid() {
+		return http.StatusBadRequest, nil, errors.New("Invalid location")
+	}
+
+	if err := s.UserRepository.SetBase(masterId, base); err != nil {
+		return http.StatusNotFound, nil, err
+	}
+	return http.StatusOK, base, nil
+}
+
+// NearestMasters returns masters of the request category ordered by distance from the request
+func (s GeoService) NearestMasters(requestId bson.ObjectID, limit int) (int, *[]models.NearbyMaster, error) {
+	var request models.Request
+	if err := s.RequestRepository.GetRequest(requestId, &request); err != nil {
+		return http.StatusNotFound, nil, err
+	}
+
+	if request.Location == nil {
+		return http.StatusConflict, nil, errors.New("Request has no location")
+	}
+
+	var categoryId *bson.ObjectID
+	if request.Category != nil {
+		categoryId = request.Category.Id
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	masters := []models.NearbyMaster{}
+	if err := s.UserRepository.GetNearestMasters(request.Location, categoryId, limit, &masters); err != nil {
+		log.Println(err)
+		return http.StatusInternalServerError, nil, err
+	}
+	return http.StatusOK, &masters, nil
+}
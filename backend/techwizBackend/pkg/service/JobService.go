@@ -0,0 +1,338 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"techwizBackend/pkg/config"
+	"techwizBackend/pkg/cron"
+	"techwizBackend/pkg/models"
+	"techwizBackend/pkg/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// JobHandler runs the job, an error schedules a retry
+type JobHandler func(job *models.Job) error
+
+type (
+	IJobService interface {
+		Register(jobType string, handler JobHandler)
+		Enqueue(jobType string, key string, payload map[string]any, runAt time.Time) (*models.Job, error)
+		Unschedule(key string) error
+		Recurring(jobType string, spec string) error
+		Run()
+		GetJobs(userId bson.ObjectID, status string, jobType string) (int, *[]models.Job, error)
+		Retry(id bson.ObjectID, userId bson.ObjectID) (int, error)
+		Cancel(id bson.ObjectID, userId bson.ObjectID) (int, error)
+	}
+
+	JobService struct {
+		JobRepository  repository.IJobRepository
+		UserRepository repository.IUserRepository
+		Config         *config.Config
+		owner          string
+		mu             sync.RWMutex
+		handlers       map[string]JobHandler
+		slots          sync.Mutex
+		running        map[string]int // jobs of the type run by the workers of the instance
+	}
+)
+
+func NewJobService(
+	jobRepository repository.IJobRepository,
+	userRepository repository.IUserRepository,
+	cfg *config.Config,
+) *JobService {
+	return &JobService{
+		JobRepository:  jobRepository,
+		UserRepository: userRepository,
+		Config:         cfg,
+		owner:          newOwner(),
+		handlers:       map[string]JobHandler{},
+		running:        map[string]int{},
+	}
+}
+
+// Register sets the handler of the job type, it must be called before Run
+func (s *JobService) Register(jobType string, handler JobHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[jobType] = handler
+}
+
+// Enqueue schedules the job at runAt. A pending job with the same key is moved instead of duplicated,
+// an empty key always creates a new job
+func (s *JobService) Enqueue(jobType string, key string, payload map[string]any, runAt time.Time) (*models.Job, error) {
+	job := models.Job{
+		Type:        jobType,
+		Key:         key,
+		Payload:     payload,
+		Status:      models.JobPending,
+		RunAt:       runAt,
+		MaxAttempts: s.Config.JobMaxAttempts,
+		CreatedAt:   time.Now(),
+	}
+
+	if key == "" {
+		if err := s.JobRepository.Create(&job); err != nil {
+			return nil, err
+		}
+		return &job, nil
+	}
+	if err := s.JobRepository.Upsert(&job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Unschedule cancels the pending job with the key
+func (s *JobService) Unschedule(key string) error {
+	return s.JobRepository.CancelByKey(key)
+}
+
+// Recurring makes sure that a single job of the type runs by the cron spec,
+// a changed spec is applied to the existing job. A canceled or failed job is scheduled again
+func (s *JobService) Recurring(jobType string, spec string) error {
+	schedule, err := cron.Parse(spec)
+	if err != nil {
+		return err
+	}
+
+	job := models.Job{
+		Type:        jobType,
+		Key:         jobType,
+		Cron:        spec,
+		RunAt:       schedule.Next(time.Now().In(s.Config.Location)),
+		MaxAttempts: s.Config.JobMaxAttempts,
+		CreatedAt:   time.Now(),
+	}
+	next := job.RunAt
+	if err := s.JobRepository.Ensure(&job); err != nil {
+		return err
+	}
+
+	if job.Cron != spec {
+		return s.JobRepository.SetCron(job.Id, spec, next)
+	}
+	return nil
+}
+
+// Run starts the workers and finishes expired jobs, it blocks and must be run in a goroutine
+func (s *JobService) Run() {
+	for i := 0; i < max(s.Config.JobWorkers, 1); i++ {
+		go s.work()
+	}
+
+	ticker := time.NewTicker(s.Config.JobPoll)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.expire()
+	}
+}
+
+// work polls for due jobs and runs them one after another
+func (s *JobService) work() {
+	ticker := time.NewTicker(s.Config.JobPoll)
+	defer ticker.Stop()
+	for range ticker.C {
+		for {
+			job, ok := s.acquire()
+			if !ok {
+				break
+			}
+			s.execute(job)
+			s.release(job.Type)
+		}
+	}
+}
+
+// acquire leases a due job. A type that already takes all workers but one is skipped,
+// so a slow handler, e.g. a dead webhook endpoint, does not hold up the other types
+func (s *JobService) acquire() (*models.Job, bool) {
+	s.slots.Lock()
+	defer s.slots.Unlock()
+
+	var skipTypes []string
+	if limit := s.Config.JobWorkers - 1; limit > 0 {
+		for jobType, count := range s.running {
+			if count >= limit {
+				skipTypes = append(skipTypes, jobType)
+			}
+		}
+	}
+
+	var job models.Job
+	err := s.JobRepository.Acquire(s.owner, s.Config.JobLease, skipTypes, &job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("failed to acquire job: %s", err)
+		return nil, false
+	}
+	s.running[job.Type]++
+	return &job, true
+}
+
+func (s *JobService) release(jobType string) {
+	s.slots.Lock()
+	defer s.slots.Unlock()
+	if s.running[jobType]--; s.running[jobType] <= 0 {
+		delete(s.running, jobType)
+	}
+}
+
+// execute runs the job and renews its lease until the handler returns
+func (s *JobService) execute(job *models.Job) {
+	done := make(chan struct{})
+	go s.heartbeat(job, done)
+	err := s.call(job)
+	close(done)
+	s.finish(job, err)
+}
+
+func (s *JobService) heartbeat(job *models.Job, done chan struct{}) {
+	ticker := time.NewTicker(s.Config.JobLease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := s.JobRepository.Extend(job, s.Config.JobLease); err != nil {
+				log.Printf("job %s %s: %s", job.Type, job.Id.Hex(), err)
+			}
+		}
+	}
+}
+
+// expire finishes the jobs whose instance has stopped on the last attempt, they are not retried
+func (s *JobService) expire() {
+	var jobs []models.Job
+	if err := s.JobRepository.GetExpired(&jobs); err != nil {
+		log.Printf("failed to get expired jobs: %s", err)
+		return
+	}
+	for i := range jobs {
+		s.finish(&jobs[i], errors.New("job lease expired"))
+	}
+}
+
+// finish records the result, a failed job is retried with backoff while it has attempts left
+func (s *JobService) finish(job *models.Job, err error) {
+	var next *time.Time
+	if job.Cron != "" {
+		if schedule, err := cron.Parse(job.Cron); err == nil {
+			at := schedule.Next(time.Now().In(s.Config.Location))
+			next = &at
+		}
+	}
+
+	if err == nil {
+		if err := s.JobRepository.Complete(job, next, ""); err != nil {
+			log.Printf("job %s %s: %s", job.Type, job.Id.Hex(), err)
+		}
+		return
+	}
+
+	log.Printf("job %s %s attempt %d failed: %s", job.Type, job.Id.Hex(), job.Attempts, err)
+	if job.Attempts < job.MaxAttempts {
+		retryAt := time.Now().Add(s.backoff(job.Attempts))
+		err = s.JobRepository.Fail(job, err.Error(), &retryAt)
+	} else if next != nil {
+		// a recurring job waits for the next run instead of failing for good
+		err = s.JobRepository.Complete(job, next, err.Error())
+	} else {
+		err = s.JobRepository.Fail(job, err.Error(), nil)
+	}
+	if err != nil {
+		log.Printf("job %s %s: %s", job.Type, job.Id.Hex(), err)
+	}
+}
+
+// call runs the handler, a panic is turned into an error
+func (s *JobService) call(job *models.Job) (err error) {
+	s.mu.RLock()
+	handler, ok := s.handlers[job.Type]
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("no handler for job type %q", job.Type)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(job)
+}
+
+// backoff doubles the delay on every attempt up to JobMaxBackoff
+func (s *JobService) backoff(attempt int) time.Duration {
+	delay := s.Config.JobBackoff
+	for i := 1; i < attempt && delay < s.Config.JobMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > s.Config.JobMaxBackoff {
+		delay = s.Config.JobMaxBackoff
+	}
+	return delay
+}
+
+func (s *JobService) GetJobs(userId bson.ObjectID, status string, jobType string) (int, *[]models.Job, error) {
+	if status, err := checkAdmin(s.UserRepository, userId, "manage jobs"); err != nil {
+		return status, nil, err
+	}
+
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	if jobType != "" {
+		filter["type"] = jobType
+	}
+
+	jobs := []models.Job{}
+	if err := s.JobRepository.Get(filter, 200, &jobs); err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	return http.StatusOK, &jobs, nil
+}
+
+func (s *JobService) Retry(id bson.ObjectID, userId bson.ObjectID) (int, error) {
+	if status, err := checkAdmin(s.UserRepository, userId, "manage jobs"); err != nil {
+		return status, err
+	}
+
+	if err := s.JobRepository.Retry(id); err != nil {
+		return http.StatusConflict, err
+	}
+	return http.StatusOK, nil
+}
+
+func (s *JobService) Cancel(id bson.ObjectID, userId bson.ObjectID) (int, error) {
+	if status, err := checkAdmin(s.UserRepository, userId, "manage jobs"); err != nil {
+		return status, err
+	}
+
+	if err := s.JobRepository.Cancel(id); err != nil {
+		return http.StatusConflict, err
+	}
+	return http.StatusOK, nil
+}
+
+// newOwner identifies the instance in job leases
+func newOwner() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b))
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"techwizBackend/pkg/config"
+	"techwizBackend/pkg/models"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func newTestJobService(repo *fakeJobRepository, workers int) *JobService {
+	return NewJobService(repo, nil, &config.Config{
+		JobLease:       time.Minute,
+		JobMaxAttempts: 3,
+		JobBackoff:     30 * time.Second,
+		JobMaxBackoff:  time.Hour,
+		JobWorkers:     workers,
+		Location:       time.UTC,
+	})
+}
+
+func TestJobServiceBackoff(t *testing.T) {
+	s := newTestJobService(&fakeJobRepository{}, 1)
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour}, // 64m is over the limit
+		{50, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := s.backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestJobServiceFinish(t *testing.T) {
+	failure := errors.New("endpoint is down")
+
+	tests := []struct {
+		name     string
+		cron     string
+		attempts int
+		err      error
+		complete bool          // Complete is called, otherwise Fail
+		next     bool          // the next run or the retry is set
+		delay    time.Duration // of the next run or the retry
+		message  string
+	}{
+		{name: "done", attempts: 1, complete: true},
+		{name: "recurring runs again", cron: "@every 5m", attempts: 1, complete: true, next: true, delay: 5 * time.Minute},
+		{name: "first failure is retried", attempts: 1, err: failure, next: true, delay: 30 * time.Second, message: failure.Error()},
+		{name: "retry is delayed more", attempts: 2, err: failure, next: true, delay: time.Minute, message: failure.Error()},
+		{name: "recurring is retried first", cron: "@every 5m", attempts: 2, err: failure, next: true, delay: time.Minute, message: failure.Error()},
+		{name: "last failure fails for good", attempts: 3, err: failure, message: failure.Error()},
+		{name: "last failure of recurring waits for the next run", cron: "@every 5m", attempts: 3, err: failure, complete: true, next: true, delay: 5 * time.Minute, message: failure.Error()},
+		{name: "invalid cron fails for good", cron: "every day", attempts: 3, err: failure, message: failure.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeJobRepository{}
+			s := newTestJobService(repo, 1)
+			job := models.Job{Id: bson.NewObjectID(), Type: "test", Cron: tt.cron, Attempts: tt.attempts, MaxAttempts: 3}
+
+			before := time.Now()
+			s.finish(&job, tt.err)
+
+			calls, other := repo.failed, repo.completed
+			if tt.complete {
+				calls, other = repo.completed, repo.failed
+			}
+			if len(calls) != 1 || len(other) != 0 {
+				t.Fatalf("completed %d, failed %d times", len(repo.completed), len(repo.failed))
+			}
+			call := calls[0]
+			if call.id != job.Id || call.message != tt.message {
+				t.Errorf("call = %s %q, want %s %q", call.id.Hex(), call.message, job.Id.Hex(), tt.message)
+			}
+			if !tt.next {
+				if call.at != nil {
+					t.Errorf("next run at %s, want none", call.at)
+				}
+				return
+			}
+			if call.at == nil {
+				t.Fatal("next run is not set")
+			}
+			if delay := call.at.Sub(before); delay < tt.delay || delay > tt.delay+time.Second {
+				t.Errorf("next run in %s, want %s", delay, tt.delay)
+			}
+		})
+	}
+}
+
+func TestJobServiceExecute(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler JobHandler
+		failed  string
+	}{
+		{name: "success", handler: func(*models.Job) error { return nil }},
+		{name: "error", handler: func(*models.Job) error { return errors.New("boom") }, failed: "boom"},
+		{name: "panic", handler: func(*models.Job) error { panic("boom") }, failed: "panic: boom"},
+		{name: "no handler", failed: `no handler for job type "test"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeJobRepository{}
+			s := newTestJobService(repo, 1)
+			if tt.handler != nil {
+				s.Register("test", tt.handler)
+			}
+
+			s.execute(&models.Job{Id: bson.NewObjectID(), Type: "test", Attempts: 1, MaxAttempts: 3})
+
+			if tt.failed == "" {
+				if len(repo.completed) != 1 || len(repo.failed) != 0 {
+					t.Fatalf("completed %d, failed %d times, want completed", len(repo.completed), len(repo.failed))
+				}
+				return
+			}
+			if len(repo.failed) != 1 || repo.failed[0].message != tt.failed || repo.failed[0].at == nil {
+				t.Fatalf("failed = %+v, want a retry with %q", repo.failed, tt.failed)
+			}
+		})
+	}
+}
+
+func TestJobServiceAcquireSkipsBusyTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+		running map[string]int
+		want    []string
+	}{
+		{name: "single worker takes any type", workers: 1, running: map[string]int{}},
+		{name: "nothing is running", workers: 4, running: map[string]int{}},
+		{name: "type below the limit", workers: 4, running: map[string]int{JobWebhook: 2, JobReminder: 1}},
+		{name: "type takes all workers but one", workers: 4, running: map[string]int{JobWebhook: 3}, want: []string{JobWebhook}},
+		{name: "two workers", workers: 2, running: map[string]int{JobWebhook: 1, JobReminder: 1}, want: []string{JobReminder, JobWebhook}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeJobRepository{due: []models.Job{{Id: bson.NewObjectID(), Type: JobSlaCheck}}}
+			s := newTestJobService(repo, tt.workers)
+			s.running = tt.running
+
+			job, ok := s.acquire()
+			if !ok || job.Type != JobSlaCheck {
+				t.Fatalf("acquire() = %v, %v", job, ok)
+			}
+			skipTypes := repo.skipTypes[0]
+			sort.Strings(skipTypes)
+			if !reflect.DeepEqual(skipTypes, tt.want) {
+				t.Errorf("skipped %v, want %v", skipTypes, tt.want)
+			}
+			if s.running[JobSlaCheck] != 1 {
+				t.Errorf("running %s = %d, want 1", JobSlaCheck, s.running[JobSlaCheck])
+			}
+
+			s.release(job.Type)
+			if _, ok := s.running[JobSlaCheck]; ok {
+				t.Errorf("%s is still running after release", JobSlaCheck)
+			}
+			if _, ok := s.acquire(); ok {
+				t.Error("acquire() without due jobs = true")
+			}
+		})
+	}
+}
+
+func TestJobServiceRecurring(t *testing.T) {
+	repo := &fakeJobRepository{}
+	s := newTestJobService(repo, 1)
+
+	if err := s.Recurring(JobSlaCheck, "@every 5m"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Recurring(JobSlaCheck, "@every 5m"); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.due) != 1 {
+		t.Fatalf("%d jobs scheduled, want 1", len(repo.due))
+	}
+
+	before := time.Now()
+	if err := s.Recurring(JobSlaCheck, "@every 1m"); err != nil {
+		t.Fatal(err)
+	}
+	job := repo.due[0]
+	if job.Cron != "@every 1m" {
+		t.Errorf("cron = %q, want the changed spec", job.Cron)
+	}
+	if delay := job.RunAt.Sub(before); delay < time.Minute || delay > time.Minute+time.Second {
+		t.Errorf("next run in %s, want 1m", delay)
+	}
+
+	if err := s.Recurring(JobSlaCheck, "every minute"); err == nil {
+		t.Error("invalid spec is accepted")
+	}
+}
@@ -1,7 +1,10 @@
 package service
 
 import (
+	"errors"
 	"net/http"
+	"strings"
+	"techwizBackend/pkg/config"
 	"techwizBackend/pkg/models"
 	"techwizBackend/pkg/repository"
 	"time"
@@ -9,16 +12,30 @@ import (
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
+const (
+	defaultMessagePage = 50
+	maxMessagePage     = 200
+)
+
 type (
 	IMessageService interface {
 		Save(message *models.Message) error
-		GetMessageByChat(chat_id bson.ObjectID) (int, *[]models.Message)
+		GetMessages(chatId bson.ObjectID, before *models.MessageAnchor, after *models.MessageAnchor, limit int) (int, *models.MessagePage, error)
+		Sync(userId bson.ObjectID, sync models.WsSyncPayload, limit int) (*[]models.Message, bool, error)
+		MarkDelivered(messageIds []bson.ObjectID, userIds []bson.ObjectID) error
+		SetCursor(userId bson.ObjectID, cursor models.MessageAnchor) error
+		Edit(userId bson.ObjectID, messageId bson.ObjectID, text string) (int, *models.Message, error)
+		Delete(userId bson.ObjectID, messageId bson.ObjectID) (int, *models.Message, error)
+		GetHistory(userId bson.ObjectID, messageId bson.ObjectID) (int, []models.MessageEdit, error)
+		SetPusher(pusher IPusher)
 	}
 
 	MessageService struct {
 		MessageRepository repository.IMessageRepository
 		ChatRepository    repository.IChatRepository
 		UserRepository    repository.IUserRepository
+		Pusher            IPusher
+		Config            *config.Config
 	}
 )
 
@@ -26,14 +43,20 @@ func NewMessageService(
 	messageRepository repository.IMessageRepository,
 	chatRepository repository.IChatRepository,
 	userRepository repository.IUserRepository,
+	cfg *config.Config,
 ) *MessageService {
 	return &MessageService{
 		MessageRepository: messageRepository,
 		ChatRepository:    chatRepository,
 		UserRepository:    userRepository,
+		Config:            cfg,
 	}
 }
 
+func (s *MessageService) SetPusher(pusher IPusher) {
+	s.Pusher = pusher
+}
+
 func (s *MessageService) Save(message *models.Message) error {
 	message.CreatedAt = time.Now()
 	// get chat id for personal chat
@@ -61,11 +84,201 @@ func (s *MessageService) Save(message *models.Message) error {
 	return nil
 }
 
-func (s *MessageService) GetMessageByChat(chat_id bson.ObjectID) (int, *[]models.Message) {
-	var messages []models.Message
-	if err := s.MessageRepository.GetMessageByChat(chat_id, &messages); err != nil {
-		return http.StatusBadRequest, nil
+// GetMessages returns a page of the chat history, an anchor by id takes the time of the message
+func (s *MessageService) GetMessages(chatId bson.ObjectID, before *models.MessageAnchor, after *models.MessageAnchor, limit int) (int, *models.MessagePage, error) {
+	if before != nil && after != nil {
+		return http.StatusBadRequest, nil, errors.New("Only one of before and after can be set")
+	}
+	if limit <= 0 {
+		limit = defaultMessagePage
+	}
+	limit = min(limit, maxMessagePage)
+
+	for _, anchor := range []*models.MessageAnchor{before, after} {
+		if anchor == nil || anchor.Id == nil {
+			continue
+		}
+		var message models.Message
+		if err := s.MessageRepository.GetById(*anchor.Id, &message); err != nil {
+			return http.StatusNotFound, nil, err
+		}
+		if message.Chat != chatId {
+			return http.StatusBadRequest, nil, errors.New("Message is not in the chat")
+		}
+		anchor.At = &message.CreatedAt
+	}
+
+	// one more message tells whether there is a next page
+	messages := []models.Message{}
+	if err := s.MessageRepository.GetPage(chatId, before, after, int64(limit+1), &messages); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	page := models.MessagePage{Messages: messages, HasMore: len(messages) > limit}
+	if page.HasMore {
+		if after != nil {
+			// the extra message is the farthest from the anchor, the newest one
+			page.Messages = messages[1:]
+		} else {
+			page.Messages = messages[:limit]
+		}
+	}
+	return http.StatusOK, &page, nil
+}
+
+// Sync returns the messages of all chats of the user after the last seen one that weren't delivered to the user,
+// more = the limit is reached. Without the last seen message the delivery cursor is used.
+// The first sync of the user has no cursor, it starts from the newest message, the history is loaded by pages
+func (s *MessageService) Sync(userId bson.ObjectID, sync models.WsSyncPayload, limit int) (*[]models.Message, bool, error) {
+	var chats []models.Chat
+	if err := s.ChatRepository.GetChats(userId, &chats); err != nil {
+		return nil, false, err
+	}
+	chatIds := make([]bson.ObjectID, 0, len(chats))
+	for _, chat := range chats {
+		if chat.Id != nil {
+			chatIds = append(chatIds, *chat.Id)
+		}
+	}
+	if len(chatIds) == 0 {
+		return &[]models.Message{}, false, nil
+	}
+
+	var after *models.MessageAnchor
+	if sync.AfterId != nil {
+		// the anchor takes the time of the message as in GetMessages
+		var message models.Message
+		if err := s.MessageRepository.GetById(*sync.AfterId, &message); err != nil {
+			return nil, false, err
+		}
+		after = &models.MessageAnchor{Id: &message.Id, At: &message.CreatedAt}
+	} else if sync.After != nil {
+		after = &models.MessageAnchor{At: sync.After}
+	} else {
+		cursor, err := s.MessageRepository.GetCursor(userId)
+		if err != nil {
+			return nil, false, err
+		}
+		if cursor == nil {
+			var newest models.Message
+			if err := s.MessageRepository.GetNewest(chatIds, &newest); err == nil {
+				if err := s.MessageRepository.SetCursor(userId, models.MessageAnchor{Id: &newest.Id, At: &newest.CreatedAt}); err != nil {
+					return nil, false, err
+				}
+			}
+			return &[]models.Message{}, false, nil
+		}
+		after = cursor
+	}
+
+	// one more message tells whether the client has to repeat
+	messages := []models.Message{}
+	if err := s.MessageRepository.GetAfter(chatIds, after, &userId, int64(limit+1), &messages); err != nil {
+		return nil, false, err
+	}
+	more := len(messages) > limit
+	if more {
+		messages = messages[:limit]
+	}
+	return &messages, more, nil
+}
+
+// MarkDelivered records that the messages are written to the connections of the users
+func (s *MessageService) MarkDelivered(messageIds []bson.ObjectID, userIds []bson.ObjectID) error {
+	if len(messageIds) == 0 || len(userIds) == 0 {
+		return nil
 	}
+	return s.MessageRepository.MarkDelivered(messageIds, userIds)
+}
 
-	return http.StatusOK, &messages
+// SetCursor is moved by sync, the messages up to it are delivered to the user
+func (s *MessageService) SetCursor(userId bson.ObjectID, cursor models.MessageAnchor) error {
+	return s.MessageRepository.SetCursor(userId, cursor)
+}
+
+// Edit changes the text of the own message within the edit window
+func (s *MessageService) Edit(userId bson.ObjectID, messageId bson.ObjectID, text string) (int, *models.Message, error) {
+	if strings.TrimSpace(text) == "" {
+		return http.StatusBadRequest, nil, errors.New("Text is required")
+	}
+
+	var message models.Message
+	if err := s.MessageRepository.GetById(messageId, &message); err != nil {
+		return http.StatusNotFound, nil, err
+	}
+	if message.SenderId != userId {
+		return http.StatusForbidden, nil, errors.New("Only the sender can edit the message")
+	}
+	if message.DeletedAt != nil {
+		return http.StatusConflict, nil, errors.New("Message is deleted")
+	}
+	if time.Since(message.CreatedAt) > s.Config.MessageEditWindow {
+		return http.StatusConflict, nil, errors.New("Edit window of the message is over")
+	}
+	if message.Text == text {
+		return http.StatusOK, &message, nil
+	}
+
+	previous := models.MessageEdit{Text: message.Text, ReplacedAt: time.Now(), ReplacedBy: userId}
+	if err := s.MessageRepository.Edit(messageId, text, previous); err != nil {
+		return http.StatusConflict, nil, err
+	}
+
+	message.Text = text
+	message.EditedAt = &previous.ReplacedAt
+	s.push(&message, models.WsMessageEdited)
+	return http.StatusOK, &message, nil
+}
+
+// Delete removes the text of the own message, the admin can delete any message
+func (s *MessageService) Delete(userId bson.ObjectID, messageId bson.ObjectID) (int, *models.Message, error) {
+	var message models.Message
+	if err := s.MessageRepository.GetById(messageId, &message); err != nil {
+		return http.StatusNotFound, nil, err
+	}
+	if message.SenderId != userId {
+		if status, err := checkAdmin(s.UserRepository, userId, "change the message of another user"); err != nil {
+			return status, nil, err
+		}
+	}
+	if message.DeletedAt != nil {
+		return http.StatusConflict, nil, errors.New("Message is already deleted")
+	}
+
+	previous := models.MessageEdit{Text: message.Text, ReplacedAt: time.Now(), ReplacedBy: userId}
+	if err := s.MessageRepository.Delete(messageId, previous); err != nil {
+		return http.StatusConflict, nil, err
+	}
+
+	message.Text = ""
+	message.DeletedAt = &previous.ReplacedAt
+	message.DeletedBy = &userId
+	s.push(&message, models.WsMessageDeleted)
+	return http.StatusOK, &message, nil
+}
+
+// GetHistory returns the previous versions of the message to the sender and the admin
+func (s *MessageService) GetHistory(userId bson.ObjectID, messageId bson.ObjectID) (int, []models.MessageEdit, error) {
+	var message models.Message
+	if err := s.MessageRepository.GetById(messageId, &message); err != nil {
+		return http.StatusNotFound, nil, err
+	}
+	if message.SenderId != userId {
+		if status, err := checkAdmin(s.UserRepository, userId, "change the message of another user"); err != nil {
+			return status, nil, err
+		}
+	}
+
+	if message.Edits == nil {
+		return http.StatusOK, []models.MessageEdit{}, nil
+	}
+	return http.StatusOK, message.Edits, nil
+}
+
+// push sends the changed message to the members of the chat
+func (s *MessageService) push(message *models.Message, eventType string) {
+	if s.Pusher == nil {
+		return
+	}
+	s.Pusher.Push(s.ChatRepository.GetRecipient(message), eventType, message)
 }
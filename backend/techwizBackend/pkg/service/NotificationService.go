@@ -0,0 +1,86 @@
+package service
+
+import (
+	"log"
+	"net/http"
+	"techwizBackend/pkg/models"
+	"techwizBackend/pkg/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type (
+	INotificationService interface {
+		Notify(userIds []bson.ObjectID, notification models.Notification)
+		GetNotifications(userId bson.ObjectID, unread bool) (int, *[]models.Notification)
+		MarkRead(userId bson.ObjectID, ids []bson.ObjectID) (int, int64, error)
+		UnreadCount(userId bson.ObjectID) (int, int64, error)
+		SetPusher(pusher IPusher)
+	}
+
+	NotificationService struct {
+		NotificationRepository repository.INotificationRepository
+		Pusher                 IPusher
+	}
+)
+
+func NewNotificationService(notificationRepository repository.INotificationRepository) *NotificationService {
+	return &NotificationService{NotificationRepository: notificationRepository}
+}
+
+func (s *NotificationService) SetPusher(pusher IPusher) {
+	s.Pusher = pusher
+}
+
+// Notify saves a copy of the notification for every user and pushes it to those who are online.
+// Errors are logged, a failed notification must not fail the event that caused it
+func (s *NotificationService) Notify(userIds []bson.ObjectID, notification models.Notification) {
+	if len(userIds) == 0 {
+		return
+	}
+
+	notifications := make([]models.Notification, len(userIds))
+	for i, userId := range userIds {
+		notifications[i] = notification
+		notifications[i].UserId = userId
+		notifications[i].Read = false
+		notifications[i].CreatedAt = time.Now()
+	}
+	if err := s.NotificationRepository.Create(notifications); err != nil {
+		log.Printf("failed to notify %s: %s", notification.Kind, err)
+		return
+	}
+
+	if s.Pusher == nil {
+		return
+	}
+	for _, item := range notifications {
+		s.Pusher.Push([]bson.ObjectID{item.UserId}, models.WsNotification, item)
+	}
+}
+
+func (s *NotificationService) GetNotifications(userId bson.ObjectID, unread bool) (int, *[]models.Notification) {
+	notifications := []models.Notification{}
+	if err := s.NotificationRepository.GetByUser(userId, unread, 100, &notifications); err != nil {
+		return http.StatusBadRequest, &[]models.Notification{}
+	}
+	return http.StatusOK, &notifications
+}
+
+// MarkRead returns the number of the marked notifications, empty ids mark all of them
+func (s *NotificationService) MarkRead(userId bson.ObjectID, ids []bson.ObjectID) (int, int64, error) {
+	count, err := s.NotificationRepository.MarkRead(userId, ids)
+	if err != nil {
+		return http.StatusInternalServerError, 0, err
+	}
+	return http.StatusOK, count, nil
+}
+
+func (s *NotificationService) UnreadCount(userId bson.ObjectID) (int, int64, error) {
+	count, err := s.NotificationRepository.CountUnread(userId)
+	if err != nil {
+		return http.StatusInternalServerError, 0, err
+	}
+	return http.StatusOK, count, nil
+}
@@ -0,0 +1,14 @@
+package service
+
+import "go.mongodb.org/mongo-driver/v2/bson"
+
+// IPusher delivers an event to the connected users, it is implemented by the websocket hub.
+// The hub is created after the services, so it is set with SetPusher
+type IPusher interface {
+	Push(userIds []bson.ObjectID, eventType string, payload any)
+}
+
+// IPresence tells whether the user is connected to the websocket of any instance
+type IPresence interface {
+	IsOnline(userId bson.ObjectID) bool
+}
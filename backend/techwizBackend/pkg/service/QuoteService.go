@@ -0,0 +1,235 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"techwizBackend/pkg/models"
+	"techwizBackend/pkg/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// maxQuoteAttempts limits the retries of concurrent proposals of the same request
+const maxQuoteAttempts = 3
+
+type (
+	IQuoteService interface {
+		Propose(requestId bson.ObjectID, userId bson.ObjectID, quote *models.Quote) (int, error)
+		GetQuotes(requestId bson.ObjectID) (int, *[]models.Quote)
+		GetQuotesByToken(token string) (int, *[]models.Quote)
+		Approve(requestId bson.ObjectID, userId bson.ObjectID, comment string) (int, error)
+		Reject(requestId bson.ObjectID, userId bson.ObjectID, comment string) (int, error)
+		ApproveByToken(token string, comment string) (int, error)
+		RejectByToken(token string, comment string) (int, error)
+	}
+
+	QuoteService struct {
+		QuoteRepository   repository.IQuoteRepository
+		RequestRepository repository.IRequestRepository
+		UserRepository    repository.IUserRepository
+		RequestService    IRequestService
+	}
+)
+
+func NewQuoteService(
+	quoteRepository repository.IQuoteRepository,
+	requestRepository repository.IRequestRepository,
+	userRepository repository.IUserRepository,
+	requestService IRequestService,
+) *QuoteService {
+	return &QuoteService{
+		QuoteRepository:   quoteRepository,
+		RequestRepository: requestRepository,
+		UserRepository:    userRepository,
+		RequestService:    requestService,
+	}
+}
+
+// Propose saves a new revision of the quote and moves the request to status 5
+func (s QuoteService) Propose(requestId bson.ObjectID, userId bson.ObjectID, quote *models.Quote) (int, error) {
+	var request models.Request
+	if err := s.RequestRepository.GetRequest(requestId, &request); err != nil {
+		return http.StatusNotFound, err
+	}
+
+	if request.Worker == nil || request.Worker.Id != userId {
+		return http.StatusForbidden, errors.New("Only the attached master can propose a quote")
+	}
+
+	switch request.Status.Code {
+	case 2, 3, 5:
+	default:
+		return http.StatusBadRequest, fmt.Errorf("quote cannot be proposed in status %v", request.Status.Code)
+	}
+
+	if len(quote.Items) > 0 {
+		quote.Total = 0
+		for i := range quote.Items {
+			if quote.Items[i].Type == "" {
+				quote.Items[i].Type = models.LineLabour
+			}
+			if !quote.Items[i].IsValid() {
+				return http.StatusBadRequest, errors.New("Invalid quote item")
+			}
+			quote.Items[i].Commission = 0
+			quote.Total += quote.Items[i].Total()
+		}
+	}
+
+	if quote.Total <= 0 {
+		return http.StatusBadRequest, errors.New("Quote total must be positive")
+	}
+
+	if quote.Reason == "" || quote.Bail <= 0 {
+		return http.StatusBadRequest, errors.New("Quote reason and bail are required")
+	}
+
+	if status, err := s.create(requestId, userId, quote); err != nil {
+		return status, err
+	}
+
+	status := models.Status{Code: 5, Reason: quote.Reason, PriceIsBail: quote.Total}
+	if code, err := s.RequestService.SetStatus(requestId, &models.Request{Status: status}); err != nil {
+		return code, err
+	}
+
+	return http.StatusCreated, nil
+}
+
+// create saves the quote as the next revision and supersedes the pending one.
+// A concurrent proposal that took the revision makes it count again
+func (s QuoteService) create(requestId bson.ObjectID, userId bson.ObjectID, quote *models.Quote) (int, error) {
+	for attempt := 0; attempt < maxQuoteAttempts; attempt++ {
+		count, err := s.QuoteRepository.CountByRequest(requestId)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		if err := s.QuoteRepository.Supersede(requestId); err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		quote.Id = bson.ObjectID{}
+		quote.RequestId = requestId
+		quote.Revision = int(count) + 1
+		quote.Status = models.QuotePending
+		quote.ProposedBy = userId
+		quote.DecidedBy = nil
+		quote.DecidedVia = ""
+		quote.DecidedAt = nil
+		quote.CreatedAt = time.Now()
+		err = s.QuoteRepository.Create(quote)
+		if errors.Is(err, repository.ErrRevisionTaken) {
+			continue
+		}
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		return http.StatusCreated, nil
+	}
+	return http.StatusConflict, repository.ErrRevisionTaken
+}
+
+func (s QuoteService) GetQuotes(requestId bson.ObjectID) (int, *[]models.Quote) {
+	var quotes []models.Quote
+	if err := s.QuoteRepository.GetByRequest(requestId, &quotes); err != nil {
+		return http.StatusBadRequest, &[]models.Quote{}
+	}
+	return http.StatusOK, &quotes
+}
+
+func (s QuoteService) GetQuotesByToken(token string) (int, *[]models.Quote) {
+	var request models.Request
+	if err := s.RequestRepository.GetRequestByToken(token, &request); err != nil {
+		return http.StatusNotFound, &[]models.Quote{}
+	}
+	return s.GetQuotes(request.Id)
+}
+
+func (s QuoteService) Approve(requestId bson.ObjectID, userId bson.ObjectID, comment string) (int, error) {
+	return s.decideByDispatcher(requestId, userId, true, comment)
+}
+
+func (s QuoteService) Reject(requestId bson.ObjectID, userId bson.ObjectID, comment string) (int, error) {
+	return s.decideByDispatcher(requestId, userId, false, comment)
+}
+
+func (s QuoteService) ApproveByToken(token string, comment string) (int, error) {
+	return s.decideByCustomer(token, true, comment)
+}
+
+func (s QuoteService) RejectByToken(token string, comment string) (int, error) {
+	return s.decideByCustomer(token, false, comment)
+}
+
+func (s QuoteService) decideByDispatcher(requestId bson.ObjectID, userId bson.ObjectID, approve bool, comment string) (int, error) {
+	var user models.User
+	if err := s.UserRepository.GetUserById(userId, &user); err != nil {
+		return http.StatusNotFound, err
+	}
+
+	if !user.IsDispatcher() {
+		return http.StatusForbidden, errors.New("Only the dispatcher can decide on a quote")
+	}
+
+	var request models.Request
+	if err := s.RequestRepository.GetRequest(requestId, &request); err != nil {
+		return http.StatusNotFound, err
+	}
+
+	quote := models.Quote{DecidedBy: &userId, DecidedVia: models.QuoteByDispatcher, Comment: comment}
+	return s.decide(&request, &quote, approve)
+}
+
+func (s QuoteService) decideByCustomer(token string, approve bool, comment string) (int, error) {
+	var request models.Request
+	if err := s.RequestRepository.GetRequestByToken(token, &request); err != nil {
+		return http.StatusNotFound, err
+	}
+
+	quote := models.Quote{DecidedVia: models.QuoteByCustomer, Comment: comment}
+	return s.decide(&request, &quote, approve)
+}
+
+// decide applies the decision to the pending quote.
+// Approval returns the request to in work at the new price,
+// rejection cancels the request with the bail of the quote
+func (s QuoteService) decide(request *models.Request, decision *models.Quote, approve bool) (int, error) {
+	if request.Status.Code != 5 {
+		return http.StatusBadRequest, errors.New("Request is not waiting for a quote decision")
+	}
+
+	var quote models.Quote
+	if err := s.QuoteRepository.GetPending(request.Id, &quote); err != nil {
+		return http.StatusNotFound, err
+	}
+
+	now := time.Now()
+	quote.DecidedBy = decision.DecidedBy
+	quote.DecidedVia = decision.DecidedVia
+	quote.Comment = decision.Comment
+	quote.DecidedAt = &now
+	if approve {
+		quote.Status = models.QuoteApproved
+	} else {
+		quote.Status = models.QuoteRejected
+	}
+
+	if err := s.QuoteRepository.Decide(&quote); err != nil {
+		return http.StatusConflict, err
+	}
+
+	if approve {
+		approved := models.Request{Status: models.Status{Code: 3}, Price: quote.Total, Items: quote.Items}
+		return s.RequestService.SetStatus(request.Id, &approved)
+	}
+
+	reason := "Quote rejected: " + quote.Reason
+	if quote.Comment != "" {
+		reason = "Quote rejected: " + quote.Comment
+	}
+	status := models.Status{Code: 6, Reason: reason, PriceIsBail: quote.Bail}
+	return s.RequestService.SetStatus(request.Id, &models.Request{Status: status})
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"techwizBackend/pkg/config"
+	"techwizBackend/pkg/models"
+	"techwizBackend/pkg/notify"
+	"techwizBackend/pkg/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// JobReminder sends the reminder of a single offset to a single recipient,
+// so a failed send to the master does not repeat the one to the customer
+const JobReminder = "request.reminder"
+
+// Recipients of the reminders
+const (
+	remindCustomer = "customer"
+	remindMaster   = "master"
+)
+
+type (
+	IReminderService interface {
+		Schedule(request *models.Request)
+		Cancel(requestId bson.ObjectID)
+		SendJob(job *models.Job) error
+	}
+
+	ReminderService struct {
+		RequestRepository repository.IRequestRepository
+		JobService        IJobService
+		Channel           notify.Channel
+		Config            *config.Config
+	}
+)
+
+func NewReminderService(
+	requestRepository repository.IRequestRepository,
+	jobService IJobService,
+	channel notify.Channel,
+	cfg *config.Config,
+) *ReminderService {
+	return &ReminderService{
+		RequestRepository: requestRepository,
+		JobService:        jobService,
+		Channel:           channel,
+		Config:            cfg,
+	}
+}
+
+// Schedule plans a reminder for every offset before the datetime of the request,
+// reminders that are already planned are moved. Requests without a master or datetime get none
+func (s ReminderService) Schedule(request *models.Request) {
+	if request.Worker == nil || request.DateTime.IsZero() || !reminderStatus(request.Status.Code) {
+		s.Cancel(request.Id)
+		return
+	}
+
+	for _, offset := range s.Config.ReminderOffsets {
+		runAt := request.DateTime.Add(-offset)
+		for _, recipient := range []string{remindCustomer, remindMaster} {
+			key := reminderKey(request.Id, offset, recipient)
+			if runAt.Before(time.Now()) {
+				// too late for this reminder
+				if err := s.JobService.Unschedule(key); err != nil {
+					log.Println(err)
+				}
+				continue
+			}
+
+			payload := map[string]any{
+				"request_id": request.Id.Hex(),
+				"datetime":   request.DateTime,
+				"offset":     offset.String(),
+				"recipient":  recipient,
+			}
+			if _, err := s.JobService.Enqueue(JobReminder, key, payload, runAt); err != nil {
+				log.Printf("failed to schedule reminder of %s: %s", request.Id.Hex(), err)
+			}
+		}
+	}
+}
+
+func (s ReminderService) Cancel(requestId bson.ObjectID) {
+	for _, offset := range s.Config.ReminderOffsets {
+		for _, recipient := range []string{remindCustomer, remindMaster} {
+			if err := s.JobService.Unschedule(reminderKey(requestId, offset, recipient)); err != nil {
+				log.Println(err)
+			}
+		}
+	}
+}
+
+// SendJob reminds the recipient of the job, a reminder of a changed request is skipped
+func (s ReminderService) SendJob(job *models.Job) error {
+	hex, _ := job.Payload["request_id"].(string)
+	requestId, err := bson.ObjectIDFromHex(hex)
+	if err != nil {
+		return errors.New("invalid request_id in payload")
+	}
+
+	var request models.Request
+	if err := s.RequestRepository.GetRequest(requestId, &request); err != nil {
+		return err
+	}
+	var datetime time.Time
+	switch value := job.Payload["datetime"].(type) {
+	case bson.DateTime:
+		datetime = value.Time()
+	case time.Time:
+		datetime = value
+	}
+	if request.Worker == nil || !reminderStatus(request.Status.Code) || !request.DateTime.Equal(datetime) {
+		return nil
+	}
+
+	at := request.DateTime.In(s.Config.Location).Format("02.01.2006 15:04")
+	switch job.Payload["recipient"] {
+	case remindCustomer:
+		return s.Channel.Send(notify.Message{
+			Phone: request.PhoneNumber,
+			Kind:  "reminder",
+			Text:  fmt.Sprintf("Напоминаем о визите мастера %s по адресу %s", at, request.Address),
+		})
+	case remindMaster:
+		return s.Channel.Send(notify.Message{
+			UserId: &request.Worker.Id,
+			Phone:  request.Worker.PhoneNumber,
+			Kind:   "reminder",
+			Text:   fmt.Sprintf("Визит %s по адресу %s, клиент %s %s", at, request.Address, request.FullName, request.PhoneNumber),
+		})
+	default:
+		return errors.New("invalid recipient in payload")
+	}
+}
+
+// reminders are sent for appointed requests and requests in work
+func reminderStatus(code int) bool {
+	return code == 2 || code == 3 || code == 5
+}
+
+func reminderKey(requestId bson.ObjectID, offset time.Duration, recipient string) string {
+	return fmt.Sprintf("reminder:%s:%s:%s", requestId.Hex(), offset, recipient)
+}
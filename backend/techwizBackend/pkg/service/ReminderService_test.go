@@ -0,0 +1,230 @@
+package service
+
+import (
+	"errors"
+	"techwizBackend/pkg/config"
+	"techwizBackend/pkg/models"
+	"techwizBackend/pkg/notify"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var testReminderOffsets = []time.Duration{24 * time.Hour, time.Hour}
+
+func newTestReminderService(requests *fakeRequestRepository, jobs *fakeJobService, channel notify.Channel) *ReminderService {
+	return NewReminderService(requests, jobs, channel, &config.Config{
+		ReminderOffsets: testReminderOffsets,
+		Location:        time.UTC,
+	})
+}
+
+func newTestRequest(code int, datetime time.Time) models.Request {
+	return models.Request{
+		Id:          bson.NewObjectID(),
+		FullName:    "Иван Петров",
+		PhoneNumber: "+79001234567",
+		Address:     "ул. Ленина, 5",
+		Status:      models.Status{Code: code},
+		DateTime:    datetime,
+		Worker:      &models.User{Id: bson.NewObjectID(), PhoneNumber: "+79007654321"},
+	}
+}
+
+func TestReminderServiceSchedule(t *testing.T) {
+	datetime := time.Now().Add(48 * time.Hour).Truncate(time.Minute)
+	request := newTestRequest(2, datetime)
+	jobs := newFakeJobService()
+	s := newTestReminderService(newFakeRequestRepository(request), jobs, &notify.Fake{})
+
+	s.Schedule(&request)
+
+	if len(jobs.jobs) != len(testReminderOffsets)*2 {
+		t.Fatalf("%d jobs scheduled, want one per offset and recipient", len(jobs.jobs))
+	}
+	for _, offset := range testReminderOffsets {
+		for _, recipient := range []string{remindCustomer, remindMaster} {
+			job, ok := jobs.jobs[reminderKey(request.Id, offset, recipient)]
+			if !ok {
+				t.Errorf("no reminder %s to %s", offset, recipient)
+				continue
+			}
+			if job.Type != JobReminder || !job.RunAt.Equal(datetime.Add(-offset)) {
+				t.Errorf("reminder %s to %s: %s at %s", offset, recipient, job.Type, job.RunAt)
+			}
+			if job.Payload["recipient"] != recipient || job.Payload["request_id"] != request.Id.Hex() {
+				t.Errorf("reminder %s to %s: payload %v", offset, recipient, job.Payload)
+			}
+		}
+	}
+}
+
+func TestReminderServiceScheduleMovesJobs(t *testing.T) {
+	request := newTestRequest(2, time.Now().Add(48*time.Hour).Truncate(time.Minute))
+	jobs := newFakeJobService()
+	s := newTestReminderService(newFakeRequestRepository(request), jobs, &notify.Fake{})
+	s.Schedule(&request)
+
+	moved := request.DateTime.Add(24 * time.Hour)
+	request.DateTime = moved
+	s.Schedule(&request)
+
+	if len(jobs.jobs) != len(testReminderOffsets)*2 {
+		t.Fatalf("%d jobs after the move, want them moved instead of added", len(jobs.jobs))
+	}
+	for key, job := range jobs.jobs {
+		if job.Payload["datetime"] != moved {
+			t.Errorf("%s: datetime %v, want %s", key, job.Payload["datetime"], moved)
+		}
+	}
+
+	// the visit in 2 hours is too late for the reminder a day before
+	request.DateTime = time.Now().Add(2 * time.Hour)
+	s.Schedule(&request)
+
+	if len(jobs.jobs) != 2 {
+		t.Fatalf("%d jobs, want only the reminders an hour before", len(jobs.jobs))
+	}
+	for _, recipient := range []string{remindCustomer, remindMaster} {
+		if _, ok := jobs.jobs[reminderKey(request.Id, time.Hour, recipient)]; !ok {
+			t.Errorf("no reminder an hour before to %s", recipient)
+		}
+	}
+}
+
+func TestReminderServiceScheduleWithoutVisit(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(request *models.Request)
+	}{
+		{"master is detached", func(request *models.Request) { request.Worker = nil }},
+		{"datetime is cleared", func(request *models.Request) { request.DateTime = time.Time{} }},
+		{"request is waiting", func(request *models.Request) { request.Status.Code = 1 }},
+		{"request is closed", func(request *models.Request) { request.Status.Code = 7 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := newTestRequest(2, time.Now().Add(48*time.Hour))
+			jobs := newFakeJobService()
+			s := newTestReminderService(newFakeRequestRepository(request), jobs, &notify.Fake{})
+			s.Schedule(&request)
+
+			tt.change(&request)
+			s.Schedule(&request)
+
+			if len(jobs.jobs) != 0 {
+				t.Errorf("%d jobs left, want the reminders canceled", len(jobs.jobs))
+			}
+		})
+	}
+}
+
+func TestReminderServiceCancelOnStatus(t *testing.T) {
+	tests := []struct {
+		code   int
+		cancel bool
+	}{
+		{3, false},
+		{4, true},
+		{6, true},
+		{7, true},
+	}
+	for _, tt := range tests {
+		t.Run(models.Status{Code: tt.code}.Name(), func(t *testing.T) {
+			request := newTestRequest(2, time.Now().Add(48*time.Hour))
+			requests := newFakeRequestRepository(request)
+			jobs := newFakeJobService()
+			reminders := newTestReminderService(requests, jobs, &notify.Fake{})
+			reminders.Schedule(&request)
+
+			s := RequestService{
+				RequestRepository:   requests,
+				ReminderService:     reminders,
+				RouteService:        fakeRouteService{},
+				NotificationService: fakeNotificationService{},
+				DocumentService:     fakeDocumentService{},
+				WebhookService:      &fakeWebhookService{},
+				Config:              &config.Config{},
+			}
+			if _, err := s.SetStatus(request.Id, &models.Request{Status: models.Status{Code: tt.code}}); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.cancel && len(jobs.jobs) != 0 {
+				t.Errorf("%d jobs left, want the reminders canceled", len(jobs.jobs))
+			}
+			if !tt.cancel && len(jobs.jobs) != len(testReminderOffsets)*2 {
+				t.Errorf("%d jobs left, want the reminders kept", len(jobs.jobs))
+			}
+		})
+	}
+}
+
+func TestReminderServiceSendJob(t *testing.T) {
+	datetime := time.Date(2026, 10, 20, 14, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		code      int
+		datetime  time.Time // stored in the payload
+		recipient string
+		err       error // of the channel
+		want      *notify.Message
+		wantErr   bool
+	}{
+		{
+			name:      "customer",
+			code:      2,
+			datetime:  datetime,
+			recipient: remindCustomer,
+			want:      &notify.Message{Phone: "+79001234567", Kind: "reminder", Text: "Напоминаем о визите мастера 20.10.2026 14:30 по адресу ул. Ленина, 5"},
+		},
+		{
+			name:      "master",
+			code:      3,
+			datetime:  datetime,
+			recipient: remindMaster,
+			want:      &notify.Message{Phone: "+79007654321", Kind: "reminder", Text: "Визит 20.10.2026 14:30 по адресу ул. Ленина, 5, клиент Иван Петров +79001234567"},
+		},
+		{name: "stale datetime is skipped", code: 2, datetime: datetime.Add(-time.Hour), recipient: remindCustomer},
+		{name: "closed request is skipped", code: 6, datetime: datetime, recipient: remindCustomer},
+		{name: "unknown recipient", code: 2, datetime: datetime, recipient: "dispatcher", wantErr: true},
+		{name: "failed send is retried", code: 2, datetime: datetime, recipient: remindCustomer, err: errors.New("sms gateway is down"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := newTestRequest(tt.code, datetime)
+			channel := &notify.Fake{Err: tt.err}
+			s := newTestReminderService(newFakeRequestRepository(request), newFakeJobService(), channel)
+
+			job := models.Job{Type: JobReminder, Payload: map[string]any{
+				"request_id": request.Id.Hex(),
+				"datetime":   bson.NewDateTimeFromTime(tt.datetime),
+				"offset":     time.Hour.String(),
+				"recipient":  tt.recipient,
+			}}
+			err := s.SendJob(&job)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SendJob() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			if tt.want == nil {
+				if len(channel.Sent) != 0 {
+					t.Errorf("sent %v, want nothing", channel.Sent)
+				}
+				return
+			}
+			if len(channel.Sent) != 1 {
+				t.Fatalf("sent %d messages, want 1", len(channel.Sent))
+			}
+			sent := channel.Sent[0]
+			if sent.Phone != tt.want.Phone || sent.Kind != tt.want.Kind || sent.Text != tt.want.Text {
+				t.Errorf("sent %+v, want %+v", sent, *tt.want)
+			}
+			if tt.recipient == remindMaster && (sent.UserId == nil || *sent.UserId != request.Worker.Id) {
+				t.Errorf("sent to user %v, want the master", sent.UserId)
+			}
+		})
+	}
+}
@@ -1,12 +1,18 @@
 package service
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/dongri/phonenumber"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"log"
+	"math"
 	"net/http"
+	"strings"
+	"techwizBackend/pkg/config"
+	"techwizBackend/pkg/geo"
 	"techwizBackend/pkg/models"
 	"techwizBackend/pkg/repository"
 	"time"
@@ -15,24 +21,59 @@ import (
 type (
 	IRequestService interface {
 		Create(request *models.Request) (int, error)
-		GetRequests() *[]models.Request
+		GetRequests(breached bool) *[]models.Request
 		GetRequest(id bson.ObjectID, request *models.Request) (int, error)
-		AttachMaster(requestId bson.ObjectID, userId bson.ObjectID, request *models.Request) (int, error)
+		AttachMaster(requestId bson.ObjectID, userId bson.ObjectID, force bool, request *models.Request) (int, error)
 		UpdateRequest(id bson.ObjectID, request *models.Request) error
 		ChangeStatus(requestId bson.ObjectID, status *models.Request) (int, error)
+		SetStatus(requestId bson.ObjectID, status *models.Request) (int, error)
 		InSpot(id bson.ObjectID) error
+		CheckIn(requestId bson.ObjectID, masterId bson.ObjectID, location *models.Point) (int, *models.VisitEvent, error)
+		CheckOut(requestId bson.ObjectID, masterId bson.ObjectID, location *models.Point) (int, *models.Visit, error)
+		ChangeDateTime(id bson.ObjectID, datetime time.Time, force bool, request *models.Request) (int, error)
+		GetBySource(sourceId bson.ObjectID) *[]models.Request
 	}
 
 	RequestService struct {
-		RequestRepository repository.IRequestRepository
-		UserRepository    repository.IUserRepository
+		RequestRepository   repository.IRequestRepository
+		UserRepository      repository.IUserRepository
+		QuoteRepository     repository.IQuoteRepository
+		DocumentService     IDocumentService
+		ScheduleService     IScheduleService
+		GeoService          IGeoService
+		RouteService        IRouteService
+		ReminderService     IReminderService
+		NotificationService INotificationService
+		WebhookService      IWebhookService
+		Config              *config.Config
 	}
 )
 
-func NewRequestService(requestRepository repository.IRequestRepository, userRepository repository.IUserRepository) *RequestService {
+func NewRequestService(
+	requestRepository repository.IRequestRepository,
+	userRepository repository.IUserRepository,
+	quoteRepository repository.IQuoteRepository,
+	documentService IDocumentService,
+	scheduleService IScheduleService,
+	geoService IGeoService,
+	routeService IRouteService,
+	reminderService IReminderService,
+	notificationService INotificationService,
+	webhookService IWebhookService,
+	cfg *config.Config,
+) *RequestService {
 	return &RequestService{
-		RequestRepository: requestRepository,
-		UserRepository:    userRepository,
+		RequestRepository:   requestRepository,
+		UserRepository:      userRepository,
+		QuoteRepository:     quoteRepository,
+		DocumentService:     documentService,
+		ScheduleService:     scheduleService,
+		GeoService:          geoService,
+		RouteService:        routeService,
+		ReminderService:     reminderService,
+		NotificationService: notificationService,
+		WebhookService:      webhookService,
+		Config:              cfg,
 	}
 }
 
@@ -42,30 +83,99 @@ func (s RequestService) Create(request *models.Request) (int, error) {
 	status := models.Status{Code: 1, Reason: ""}
 	request.Status = status
 	request.CreatedAt = time.Now()
+	request.StatusAt = request.CreatedAt
+	token, err := newTrackingToken()
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	request.TrackingToken = token
+
+	// coordinates set by hand are kept, otherwise the address is geocoded.
+	// The request is created anyway, the location can be set later.
+	// Without a geocoder there is nothing to warn about
+	if request.Location != nil && !request.Location.IsValid() {
+		return http.StatusBadRequest, errors.New("Invalid location")
+	}
+	if request.Location == nil && request.Address != "" {
+		location, err := s.GeoService.Geocode(request.Address)
+		if err != nil && !errors.Is(err, geo.ErrDisabled) {
+			request.Warnings = append(request.Warnings, fmt.Sprintf("address is not geocoded: %s", err))
+		}
+		request.Location = location
+	}
 
 	if err := s.RequestRepository.Create(request); err != nil {
 		return http.StatusBadRequest, err
 	}
+	s.emit(models.EventRequestCreated, request.Id)
 	return http.StatusCreated, nil
 }
 
-func (s RequestService) GetRequests() *[]models.Request {
+// GetRequests returns all requests, breached = only requests that broke the SLA
+func (s RequestService) GetRequests(breached bool) *[]models.Request {
+	filter := bson.M{}
+	if breached {
+		filter["sla"] = bson.M{"$exists": true}
+	}
+
+	var requests []models.Request
+	if err := s.RequestRepository.GetRequests(filter, &requests); err != nil {
+		return &[]models.Request{}
+	}
+	return &requests
+}
+
+// GetBySource returns the requests created with the partner api key
+func (s RequestService) GetBySource(sourceId bson.ObjectID) *[]models.Request {
 	var requests []models.Request
-	if err := s.RequestRepository.GetRequests(&requests); err != nil {
+	if err := s.RequestRepository.GetRequests(bson.M{"source_id": sourceId}, &requests); err != nil {
 		return &[]models.Request{}
 	}
 	return &requests
 }
 
-func (s RequestService) AttachMaster(requestId bson.ObjectID, userId bson.ObjectID, request *models.Request) (int, error) {
+// AttachMaster rejects the master if the visit conflicts with his schedule,
+// with force the master is attached and the conflicts are returned as warnings
+func (s RequestService) AttachMaster(requestId bson.ObjectID, userId bson.ObjectID, force bool, request *models.Request) (int, error) {
 	var user models.User
 	if err := s.UserRepository.GetUserById(userId, &user); err != nil {
 		return http.StatusBadRequest, err
 	}
+
+	var current models.Request
+	if err := s.RequestRepository.GetRequest(requestId, &current); err != nil {
+		return http.StatusNotFound, err
+	}
+
+	conflicts, err := s.ScheduleService.CheckAvailability(userId, &current)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if len(conflicts) > 0 && !force {
+		return http.StatusConflict, fmt.Errorf("master is not available: %s", strings.Join(conflicts, "; "))
+	}
+
 	request.Commission = user.Commission
 	if err := s.RequestRepository.AttachMaster(requestId, userId, request); err != nil {
 		return http.StatusBadRequest, err
 	}
+	request.Warnings = conflicts
+
+	// routes of the new and the previous master
+	var attached models.Request
+	if err := s.RequestRepository.GetRequest(requestId, &attached); err == nil {
+		s.RouteService.Replan(&attached)
+		s.ReminderService.Schedule(&attached)
+		s.NotificationService.Notify([]bson.ObjectID{userId}, models.Notification{
+			Kind:      models.NotifyRequestAttached,
+			Text:      fmt.Sprintf("Вам назначена заявка по адресу %s", attached.Address),
+			RequestId: &requestId,
+		})
+		s.WebhookService.Emit(models.EventRequestAttached, &attached)
+	}
+	if current.Worker != nil && current.Worker.Id != userId {
+		s.RouteService.Replan(&current)
+	}
 	return http.StatusOK, nil
 }
 
@@ -86,11 +196,20 @@ func (s RequestService) UpdateRequest(id bson.ObjectID, update *models.Request)
 	return nil
 }
 
+// ChangeStatus sets the status by hand. A request that leaves status 5 without a decision
+// supersedes its pending quote, so it cannot be approved later
 func (s RequestService) ChangeStatus(requestId bson.ObjectID, request *models.Request) (int, error) {
 	if request.Status.Code == 3 || request.Status.Code == 4 {
 		request.Status.Reason = ""
 		request.Status.PriceIsBail = 0
-	} else if request.Status.Code == 5 || request.Status.Code == 6 {
+		if request.Status.Code == 4 {
+			if status, err := s.prepareItems(requestId, request); err != nil {
+				return status, err
+			}
+		}
+	} else if request.Status.Code == 5 {
+		return http.StatusBadRequest, errors.New("status code 5 is set by proposing a quote")
+	} else if request.Status.Code == 6 {
 		if request.Status.Reason == "" || request.Status.PriceIsBail == 0 {
 			return http.StatusBadRequest, fmt.Errorf("status code %v is not valid reason or price", request.Status.Code)
 		}
@@ -100,15 +219,263 @@ func (s RequestService) ChangeStatus(requestId bson.ObjectID, request *models.Re
 		}
 		request.Status.PriceIsBail = 0
 	}
+	if request.Status.Code != 4 {
+		// only completion and an approved quote change the price
+		request.Price = 0
+		request.Items = nil
+	}
+
+	var current models.Request
+	if err := s.RequestRepository.GetRequest(requestId, &current); err != nil {
+		return http.StatusNotFound, err
+	}
+	if current.Status.Code == 5 {
+		if err := s.QuoteRepository.Supersede(requestId); err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
+
+	return s.SetStatus(requestId, request)
+}
 
-	err := s.RequestRepository.ChangeStatus(requestId, request)
+// SetStatus saves the status without the checks of ChangeStatus and runs the side effects:
+// routes, reminders, notifications, webhooks and documents. Check-in and quotes change the status only here,
+// the price of status 3 is set by the approved quote
+func (s RequestService) SetStatus(requestId bson.ObjectID, request *models.Request) (int, error) {
+	var err error
+	if request.Status.Code == 3 && request.Price > 0 {
+		err = s.RequestRepository.ChangePrice(requestId, request.Price, request.Items, request.Status)
+	} else {
+		err = s.RequestRepository.ChangeStatus(requestId, request)
+	}
 	if err != nil {
 		return http.StatusInternalServerError, fmt.Errorf("%s", err.Error())
 	}
 
+	// the canceled visit is removed from the route
+	if request.Status.Code == 6 || request.Status.Code == 7 {
+		var canceled models.Request
+		if err := s.RequestRepository.GetRequest(requestId, &canceled); err == nil {
+			s.RouteService.Replan(&canceled)
+		}
+	}
+	if request.Status.Code == 4 || request.Status.Code == 6 || request.Status.Code == 7 {
+		s.ReminderService.Cancel(requestId)
+	}
+	s.notifyStatus(requestId)
+	s.emit(models.EventRequestStatus, requestId)
+	if request.Status.Code == 4 {
+		s.emit(models.EventRequestDone, requestId)
+	}
+
+	// the request is already completed, documents can be generated again later
+	if request.Status.Code == 4 {
+		if _, err := s.DocumentService.Generate(requestId); err != nil {
+			log.Printf("failed to generate documents for request %s: %s", requestId.Hex(), err)
+		}
+	}
+
 	return http.StatusOK, nil
 }
 
 func (s RequestService) InSpot(id bson.ObjectID) error {
 	return s.RequestRepository.InSpot(id)
 }
+
+// ChangeDateTime moves the visit, the schedule of the attached master is checked as in AttachMaster.
+// Reminders and routes of both days follow the new datetime
+func (s RequestService) ChangeDateTime(id bson.ObjectID, datetime time.Time, force bool, request *models.Request) (int, error) {
+	if datetime.IsZero() {
+		return http.StatusBadRequest, errors.New("Datetime is required")
+	}
+
+	var current models.Request
+	if err := s.RequestRepository.GetRequest(id, &current); err != nil {
+		return http.StatusNotFound, err
+	}
+	if current.Status.Code == 4 || current.Status.Code == 6 || current.Status.Code == 7 {
+		return http.StatusConflict, errors.New("Request is already closed")
+	}
+
+	var conflicts []string
+	if current.Worker != nil {
+		moved := current
+		moved.DateTime = datetime
+		var err error
+		if conflicts, err = s.ScheduleService.CheckAvailability(current.Worker.Id, &moved); err != nil {
+			return http.StatusInternalServerError, err
+		}
+		if len(conflicts) > 0 && !force {
+			return http.StatusConflict, fmt.Errorf("master is not available: %s", strings.Join(conflicts, "; "))
+		}
+	}
+
+	if err := s.RequestRepository.ChangeDateTime(id, datetime); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if err := s.RequestRepository.GetRequest(id, request); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	request.Warnings = conflicts
+
+	s.RouteService.Replan(&current)
+	s.RouteService.Replan(request)
+	s.ReminderService.Schedule(request)
+	return http.StatusOK, nil
+}
+
+// CheckIn records the arrival of the attached master and starts the work.
+// The master must be within Config.CheckInRadius if both locations are known
+func (s RequestService) CheckIn(requestId bson.ObjectID, masterId bson.ObjectID, location *models.Point) (int, *models.VisitEvent, error) {
+	var request models.Request
+	if err := s.RequestRepository.GetRequest(requestId, &request); err != nil {
+		return http.StatusNotFound, nil, err
+	}
+	if request.Status.Code != 2 && request.Status.Code != 3 {
+		return http.StatusConflict, nil, errors.New("Only appointed requests can be checked in")
+	}
+
+	event, status, err := s.visitEvent(&request, masterId, location)
+	if err != nil {
+		return status, nil, err
+	}
+	if event.Distance != nil && s.Config.CheckInRadius > 0 && *event.Distance > s.Config.CheckInRadius {
+		return http.StatusConflict, nil, fmt.Errorf("master is %.0f m from the request, allowed %.0f m", *event.Distance, s.Config.CheckInRadius)
+	}
+
+	if err := s.RequestRepository.CheckIn(requestId, event); err != nil {
+		return http.StatusConflict, nil, err
+	}
+
+	if request.Status.Code != 3 {
+		if status, err := s.SetStatus(requestId, &models.Request{Status: models.Status{Code: 3}}); err != nil {
+			return status, nil, err
+		}
+	}
+	return http.StatusOK, event, nil
+}
+
+// CheckOut records the departure of the master, the request status is not changed.
+// The distance is only recorded, the master may leave from anywhere
+func (s RequestService) CheckOut(requestId bson.ObjectID, masterId bson.ObjectID, location *models.Point) (int, *models.Visit, error) {
+	var request models.Request
+	if err := s.RequestRepository.GetRequest(requestId, &request); err != nil {
+		return http.StatusNotFound, nil, err
+	}
+	if request.Visit == nil || request.Visit.CheckIn == nil {
+		return http.StatusConflict, nil, errors.New("Master has not checked in")
+	}
+
+	event, status, err := s.visitEvent(&request, masterId, location)
+	if err != nil {
+		return status, nil, err
+	}
+
+	visit := request.Visit
+	visit.CheckOut = event
+	visit.OnSiteMinutes = int(event.At.Sub(visit.CheckIn.At).Round(time.Minute).Minutes())
+	if err := s.RequestRepository.CheckOut(requestId, event, visit.OnSiteMinutes); err != nil {
+		return http.StatusConflict, nil, err
+	}
+	return http.StatusOK, visit, nil
+}
+
+// visitEvent checks that the event is made by the attached master and measures the distance to the request
+func (s RequestService) visitEvent(request *models.Request, masterId bson.ObjectID, location *models.Point) (*models.VisitEvent, int, error) {
+	if request.Worker == nil || request.Worker.Id != masterId {
+		return nil, http.StatusForbidden, errors.New("Master is not attached to the request")
+	}
+
+	event := models.VisitEvent{At: time.Now(), MasterId: masterId}
+	if location == nil {
+		return &event, http.StatusOK, nil
+	}
+	if !location.IsValid() {
+		return nil, http.StatusBadRequest, errors.New("Invalid location")
+	}
+	event.Location = location
+
+	if request.Location != nil {
+		distance := math.Round(geo.Distance(location, request.Location))
+		event.Distance = &distance
+	}
+	return &event, http.StatusOK, nil
+}
+
+// notifyStatus tells the master about the new status, completion also credits the balance
+func (s RequestService) notifyStatus(requestId bson.ObjectID) {
+	var request models.Request
+	if err := s.RequestRepository.GetRequest(requestId, &request); err != nil || request.Worker == nil {
+		return
+	}
+
+	master := []bson.ObjectID{request.Worker.Id}
+	s.NotificationService.Notify(master, models.Notification{
+		Kind:      models.NotifyRequestStatus,
+		Text:      fmt.Sprintf("Статус заявки по адресу %s изменён на «%s»", request.Address, request.Status.Name()),
+		RequestId: &requestId,
+		Data:      map[string]any{"status": request.Status},
+	})
+
+	if request.Status.Code == 4 {
+		amount := request.Price - request.CommissionTotal()
+		s.NotificationService.Notify(master, models.Notification{
+			Kind:      models.NotifyBalanceCredited,
+			Text:      fmt.Sprintf("На баланс зачислено %.2f руб.", amount),
+			RequestId: &requestId,
+			Data:      map[string]any{"amount": amount},
+		})
+	}
+}
+
+// emit sends the current state of the request to the webhooks
+func (s RequestService) emit(event string, requestId bson.ObjectID) {
+	var request models.Request
+	if err := s.RequestRepository.GetRequest(requestId, &request); err != nil {
+		log.Printf("failed to emit %s for request %s: %s", event, requestId.Hex(), err)
+		return
+	}
+	s.WebhookService.Emit(event, &request)
+}
+
+func newTrackingToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// prepareItems sets the commission of every line item and the total price of the completed request.
+// Items from the body replace the stored ones, a single price without items is kept as is
+func (s RequestService) prepareItems(requestId bson.ObjectID, request *models.Request) (int, error) {
+	var current models.Request
+	if err := s.RequestRepository.GetRequest(requestId, &current); err != nil {
+		return http.StatusNotFound, err
+	}
+
+	if len(request.Items) == 0 {
+		if request.Price > 0 || len(current.Items) == 0 {
+			request.Items = nil
+			return http.StatusOK, nil
+		}
+		request.Items = current.Items
+	}
+
+	for i := range request.Items {
+		if request.Items[i].Type == "" {
+			request.Items[i].Type = models.LineLabour
+		}
+		if !request.Items[i].IsValid() {
+			return http.StatusBadRequest, errors.New("Invalid line item")
+		}
+		if rate, ok := s.Config.LineCommission[request.Items[i].Type]; ok {
+			request.Items[i].Commission = rate
+		} else {
+			request.Items[i].Commission = current.Commission
+		}
+	}
+	request.Price = request.ItemsTotal()
+
+	return http.StatusOK, nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"net/http"
+	"strings"
+	"techwizBackend/pkg/config"
+	"techwizBackend/pkg/geo"
+	"techwizBackend/pkg/models"
+	"testing"
+)
+
+func TestRequestServiceCreateGeocoding(t *testing.T) {
+	gazetteer := geo.NewGazetteer(map[string][2]float64{
+		"ул. Ленина, 5": {37.5, 55.5},
+	})
+
+	tests := []struct {
+		name     string
+		geocoder geo.Geocoder
+		address  string
+		location *models.Point
+		status   int
+		want     *models.Point
+		warning  string
+	}{
+		{
+			name:     "geocoded address",
+			geocoder: gazetteer,
+			address:  "Москва, ул. Ленина, 5, кв. 12",
+			status:   http.StatusCreated,
+			want:     models.NewPoint(55.5, 37.5),
+		},
+		{
+			name:     "unknown address is a warning",
+			geocoder: gazetteer,
+			address:  "ул. Гагарина, 1",
+			status:   http.StatusCreated,
+			warning:  "address is not geocoded: " + geo.ErrNotFound.Error(),
+		},
+		{
+			name:     "disabled geocoding is not a warning",
+			geocoder: geo.None{},
+			address:  "ул. Ленина, 5",
+			status:   http.StatusCreated,
+		},
+		{
+			name:     "location set by hand is kept",
+			geocoder: gazetteer,
+			address:  "ул. Ленина, 5",
+			location: models.NewPoint(59.9, 30.3),
+			status:   http.StatusCreated,
+			want:     models.NewPoint(59.9, 30.3),
+		},
+		{
+			name:     "invalid location",
+			geocoder: gazetteer,
+			address:  "ул. Ленина, 5",
+			location: models.NewPoint(91, 30.3),
+			status:   http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := newFakeRequestRepository()
+			webhooks := &fakeWebhookService{}
+			s := RequestService{
+				RequestRepository: requests,
+				GeoService:        NewGeoService(requests, nil, tt.geocoder),
+				WebhookService:    webhooks,
+				Config:            &config.Config{},
+			}
+
+			request := models.Request{Address: tt.address, PhoneNumber: "+79001234567", Location: tt.location}
+			status, err := s.Create(&request)
+			if status != tt.status {
+				t.Fatalf("Create() status = %d, want %d (%v)", status, tt.status, err)
+			}
+			if status != http.StatusCreated {
+				if len(requests.requests) != 0 {
+					t.Errorf("request is saved with status %d", status)
+				}
+				return
+			}
+
+			stored := requests.requests[request.Id]
+			switch {
+			case tt.want == nil && stored.Location != nil:
+				t.Errorf("Location = %v, want none", stored.Location.Coordinates)
+			case tt.want != nil && stored.Location == nil:
+				t.Errorf("Location is not set, want %v", tt.want.Coordinates)
+			case tt.want != nil && (stored.Location.Lat() != tt.want.Lat() || stored.Location.Lng() != tt.want.Lng()):
+				t.Errorf("Location = %v, want %v", stored.Location.Coordinates, tt.want.Coordinates)
+			}
+
+			warnings := strings.Join(request.Warnings, "; ")
+			if warnings != tt.warning {
+				t.Errorf("Warnings = %q, want %q", warnings, tt.warning)
+			}
+			if len(webhooks.events) != 1 || webhooks.events[0] != models.EventRequestCreated {
+				t.Errorf("emitted %v, want [%s]", webhooks.events, models.EventRequestCreated)
+			}
+		})
+	}
+}
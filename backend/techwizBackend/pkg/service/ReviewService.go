@@ -0,0 +1,115 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"techwizBackend/pkg/models"
+	"techwizBackend/pkg/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type (
+	IReviewService interface {
+		Create(requestId bson.ObjectID, userId bson.ObjectID, review *models.Review) (int, error)
+		CreateByToken(token string, review *models.Review) (int, error)
+		GetByRequest(requestId bson.ObjectID, review *models.Review) (int, error)
+		GetByMaster(masterId bson.ObjectID) (int, *[]models.Review)
+	}
+
+	ReviewService struct {
+		ReviewRepository  repository.IReviewRepository
+		RequestRepository repository.IRequestRepository
+		UserRepository    repository.IUserRepository
+	}
+)
+
+func NewReviewService(
+	reviewRepository repository.IReviewRepository,
+	requestRepository repository.IRequestRepository,
+	userRepository repository.IUserRepository,
+) *ReviewService {
+	return &ReviewService{
+		ReviewRepository:  reviewRepository,
+		RequestRepository: requestRepository,
+		UserRepository:    userRepository,
+	}
+}
+
+// Create saves the review left by the dispatcher
+func (s ReviewService) Create(requestId bson.ObjectID, userId bson.ObjectID, review *models.Review) (int, error) {
+	var user models.User
+	if err := s.UserRepository.GetUserById(userId, &user); err != nil {
+		return http.StatusNotFound, err
+	}
+
+	if !user.IsDispatcher() {
+		return http.StatusForbidden, errors.New("Only the dispatcher can leave a review")
+	}
+
+	var request models.Request
+	if err := s.RequestRepository.GetRequest(requestId, &request); err != nil {
+		return http.StatusNotFound, err
+	}
+
+	review.Source = models.ReviewByDispatcher
+	review.AuthorId = &userId
+	return s.create(&request, review)
+}
+
+// CreateByToken saves the review left by the customer
+func (s ReviewService) CreateByToken(token string, review *models.Review) (int, error) {
+	var request models.Request
+	if err := s.RequestRepository.GetRequestByToken(token, &request); err != nil {
+		return http.StatusNotFound, err
+	}
+
+	review.Source = models.ReviewByCustomer
+	review.AuthorId = nil
+	return s.create(&request, review)
+}
+
+func (s ReviewService) create(request *models.Request, review *models.Review) (int, error) {
+	if request.Status.Code != 4 || request.Worker == nil {
+		return http.StatusBadRequest, errors.New("Only a completed request can be reviewed")
+	}
+
+	if !review.IsValid() {
+		return http.StatusBadRequest, errors.New("Rating must be from 1 to 5")
+	}
+
+	review.Id = bson.ObjectID{}
+	review.RequestId = request.Id
+	review.MasterId = request.Worker.Id
+	review.CreatedAt = time.Now()
+	if err := s.ReviewRepository.Create(review); err != nil {
+		return http.StatusConflict, err
+	}
+
+	rating, count, err := s.ReviewRepository.RatingOf(review.MasterId)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	if err := s.UserRepository.SetRating(review.MasterId, rating, count); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return http.StatusCreated, nil
+}
+
+func (s ReviewService) GetByRequest(requestId bson.ObjectID, review *models.Review) (int, error) {
+	if err := s.ReviewRepository.GetByRequest(requestId, review); err != nil {
+		return http.StatusNotFound, err
+	}
+	return http.StatusOK, nil
+}
+
+func (s ReviewService) GetByMaster(masterId bson.ObjectID) (int, *[]models.Review) {
+	var reviews []models.Review
+	if err := s.ReviewRepository.GetByMaster(masterId, &reviews); err != nil {
+		return http.StatusBadRequest, &[]models.Review{}
+	}
+	return http.StatusOK, &reviews
+}
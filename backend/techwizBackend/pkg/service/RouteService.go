@@ -0,0 +1,241 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"techwizBackend/pkg/config"
+	"techwizBackend/pkg/geo"
+	"techwizBackend/pkg/models"
+	"techwizBackend/pkg/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type (
+	IRouteService interface {
+		GetRoute(masterId bson.ObjectID, date string) (int, *models.Route, error)
+		Plan(masterId bson.ObjectID, date string) (int, *models.Route, error)
+		Replan(request *models.Request)
+	}
+
+	RouteService struct {
+		RouteRepository   repository.IRouteRepository
+		RequestRepository repository.IRequestRepository
+		UserRepository    repository.IUserRepository
+		ScheduleService   IScheduleService
+		Config            *config.Config
+	}
+)
+
+func NewRouteService(
+	routeRepository repository.IRouteRepository,
+	requestRepository repository.IRequestRepository,
+	userRepository repository.IUserRepository,
+	scheduleService IScheduleService,
+	cfg *config.Config,
+) *RouteService {
+	return &RouteService{
+		RouteRepository:   routeRepository,
+		RequestRepository: requestRepository,
+		UserRepository:    userRepository,
+		ScheduleService:   scheduleService,
+		Config:            cfg,
+	}
+}
+
+// GetRoute returns the saved route, it is planned again if the visits of the day have changed
+func (s RouteService) GetRoute(masterId bson.ObjectID, date string) (int, *models.Route, error) {
+	day, err := time.ParseInLocation(time.DateOnly, date, s.Config.Location)
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.New("Invalid date")
+	}
+
+	var requests []models.Request
+	if err := s.RequestRepository.GetByWorker(masterId, day, day.AddDate(0, 0, 1), &requests); err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	var route models.Route
+	if err := s.RouteRepository.Get(masterId, date, &route); err == nil && sameVisits(&route, requests) {
+		return http.StatusOK, &route, nil
+	}
+	return s.plan(masterId, date, requests)
+}
+
+// Plan builds the route of the day again and saves it
+func (s RouteService) Plan(masterId bson.ObjectID, date string) (int, *models.Route, error) {
+	day, err := time.ParseInLocation(time.DateOnly, date, s.Config.Location)
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.New("Invalid date")
+	}
+
+	var requests []models.Request
+	if err := s.RequestRepository.GetByWorker(masterId, day, day.AddDate(0, 0, 1), &requests); err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	return s.plan(masterId, date, requests)
+}
+
+// Replan updates the route of the day of the request after it was attached or canceled
+func (s RouteService) Replan(request *models.Request) {
+	if request.Worker == nil || request.DateTime.IsZero() {
+		return
+	}
+	date := request.DateTime.In(s.Config.Location).Format(time.DateOnly)
+	if _, _, err := s.Plan(request.Worker.Id, date); err != nil {
+		log.Printf("failed to plan route of %s for %s: %s", request.Worker.Id.Hex(), date, err)
+	}
+}
+
+// plan orders the visits greedily: the next stop is the one that can be started earliest,
+// ties are broken by the distance. A visit starts not before its datetime,
+// arrival after datetime + RouteWindow is marked as late
+func (s RouteService) plan(masterId bson.ObjectID, date string, requests []models.Request) (int, *models.Route, error) {
+	var master models.User
+	if err := s.UserRepository.GetUserById(masterId, &master); err != nil {
+		return http.StatusNotFound, nil, err
+	}
+
+	route := models.Route{
+		MasterId:  masterId,
+		Date:      date,
+		Start:     master.Base,
+		Stops:     []models.RouteStop{},
+		UpdatedAt: time.Now(),
+	}
+
+	var located, unlocated []models.Request
+	for _, request := range requests {
+		if request.Location != nil {
+			located = append(located, request)
+		} else {
+			unlocated = append(unlocated, request)
+		}
+	}
+
+	position := master.Base
+	var clock time.Time
+	if len(located) > 0 {
+		// the master leaves the base in time for the earliest visit
+		first := located[0]
+		for _, request := range located {
+			if request.DateTime.Before(first.DateTime) {
+				first = request
+			}
+		}
+		clock = first.DateTime.Add(-s.travel(position, first.Location))
+	}
+	if position == nil {
+		route.Warnings = append(route.Warnings, "master has no base, the route starts at the first visit")
+	}
+
+	for len(located) > 0 {
+		best := -1
+		var bestStart time.Time
+		var bestDistance float64
+		for i, request := range located {
+			distance := s.distance(position, request.Location)
+			start := maxTime(clock.Add(s.travelOf(distance)), request.DateTime)
+			if best == -1 || start.Before(bestStart) || (start.Equal(bestStart) && distance < bestDistance) {
+				best, bestStart, bestDistance = i, start, distance
+			}
+		}
+
+		request := located[best]
+		travel := s.travelOf(bestDistance)
+		arrival := clock.Add(travel)
+		stop := models.RouteStop{
+			RequestId:     request.Id,
+			Address:       request.Address,
+			Location:      request.Location,
+			DateTime:      request.DateTime,
+			Arrival:       arrival,
+			Departure:     bestStart.Add(s.ScheduleService.JobDuration(request.Category)),
+			Distance:      math.Round(bestDistance),
+			TravelMinutes: int(travel.Round(time.Minute).Minutes()),
+			Late:          arrival.After(request.DateTime.Add(s.Config.RouteWindow)),
+		}
+		if stop.Late {
+			route.Warnings = append(route.Warnings, fmt.Sprintf("request %s is reached %s late",
+				request.Id.Hex(), arrival.Sub(request.DateTime).Round(time.Minute)))
+		}
+
+		route.Stops = append(route.Stops, stop)
+		route.Distance += stop.Distance
+		route.TravelMinutes += stop.TravelMinutes
+		position = request.Location
+		clock = stop.Departure
+		located = append(located[:best], located[best+1:]...)
+	}
+
+	// visits without coordinates keep their time order at the end
+	sort.Slice(unlocated, func(i, j int) bool { return unlocated[i].DateTime.Before(unlocated[j].DateTime) })
+	for _, request := range unlocated {
+		route.Stops = append(route.Stops, models.RouteStop{
+			RequestId: request.Id,
+			Address:   request.Address,
+			DateTime:  request.DateTime,
+			Arrival:   request.DateTime,
+			Departure: request.DateTime.Add(s.ScheduleService.JobDuration(request.Category)),
+		})
+		route.Warnings = append(route.Warnings, fmt.Sprintf("request %s has no location", request.Id.Hex()))
+	}
+
+	if err := s.RouteRepository.Save(&route); err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	return http.StatusOK, &route, nil
+}
+
+// distance is zero from an unknown position
+func (s RouteService) distance(from *models.Point, to *models.Point) float64 {
+	if from == nil || to == nil {
+		return 0
+	}
+	return geo.Distance(from, to)
+}
+
+func (s RouteService) travel(from *models.Point, to *models.Point) time.Duration {
+	return s.travelOf(s.distance(from, to))
+}
+
+func (s RouteService) travelOf(meters float64) time.Duration {
+	if s.Config.RouteSpeed <= 0 {
+		return 0
+	}
+	hours := meters / 1000 / s.Config.RouteSpeed
+	return time.Duration(hours * float64(time.Hour))
+}
+
+// sameVisits checks that the route has exactly the given requests at the same time and place
+func sameVisits(route *models.Route, requests []models.Request) bool {
+	if len(route.Stops) != len(requests) {
+		return false
+	}
+	stops := map[bson.ObjectID]models.RouteStop{}
+	for _, stop := range route.Stops {
+		stops[stop.RequestId] = stop
+	}
+	for _, request := range requests {
+		stop, ok := stops[request.Id]
+		if !ok || !stop.DateTime.Equal(request.DateTime) || (stop.Location == nil) != (request.Location == nil) {
+			return false
+		}
+		if stop.Location != nil && (stop.Location.Lat() != request.Location.Lat() || stop.Location.Lng() != request.Location.Lng()) {
+			return false
+		}
+	}
+	return true
+}
+
+func maxTime(a time.Time, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
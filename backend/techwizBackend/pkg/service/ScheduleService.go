@@ -0,0 +1,269 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"techwizBackend/pkg/config"
+	"techwizBackend/pkg/models"
+	"techwizBackend/pkg/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type (
+	IScheduleService interface {
+		GetSchedule(masterId bson.ObjectID) (int, *models.Schedule, error)
+		UpdateSchedule(masterId bson.ObjectID, schedule *models.Schedule) (int, error)
+		CheckAvailability(masterId bson.ObjectID, request *models.Request) ([]string, error)
+		FreeSlots(masterId bson.ObjectID, date string, categoryId *bson.ObjectID, duration time.Duration) (int, *[]models.TimeSlot, error)
+		JobDuration(category *models.Category) time.Duration
+	}
+
+	ScheduleService struct {
+		UserRepository     repository.IUserRepository
+		RequestRepository  repository.IRequestRepository
+		CategoryRepository repository.ICategoryRepository
+		Config             *config.Config
+	}
+)
+
+func NewScheduleService(
+	userRepository repository.IUserRepository,
+	requestRepository repository.IRequestRepository,
+	categoryRepository repository.ICategoryRepository,
+	cfg *config.Config,
+) *ScheduleService {
+	return &ScheduleService{
+		UserRepository:     userRepository,
+		RequestRepository:  requestRepository,
+		CategoryRepository: categoryRepository,
+		Config:             cfg,
+	}
+}
+
+// GetSchedule returns the schedule of the master, or the default one if it is not set
+func (s ScheduleService) GetSchedule(masterId bson.ObjectID) (int, *models.Schedule, error) {
+	var master models.User
+	if err := s.UserRepository.GetUserById(masterId, &master); err != nil {
+		return http.StatusNotFound, nil, err
+	}
+	return http.StatusOK, s.scheduleOf(&master), nil
+}
+
+func (s ScheduleService) UpdateSchedule(masterId bson.ObjectID, schedule *models.Schedule) (int, error) {
+	for _, hours := range schedule.WorkingHours {
+		if hours.Weekday < 0 || hours.Weekday > 6 {
+			return http.StatusBadRequest, fmt.Errorf("invalid weekday %d", hours.Weekday)
+		}
+		start, err1 := time.Parse("15:04", hours.Start)
+		end, err2 := time.Parse("15:04", hours.End)
+		if err1 != nil || err2 != nil || !start.Before(end) {
+			return http.StatusBadRequest, fmt.Errorf("invalid working hours %s-%s", hours.Start, hours.End)
+		}
+	}
+	for _, day := range schedule.DaysOff {
+		if _, err := time.Parse(time.DateOnly, day); err != nil {
+			return http.StatusBadRequest, fmt.Errorf("invalid day off %s", day)
+		}
+	}
+	for _, slot := range schedule.Blocked {
+		if !slot.From.Before(slot.To) {
+			return http.StatusBadRequest, errors.New("blocked slot must end after it starts")
+		}
+	}
+
+	var master models.User
+	if err := s.UserRepository.GetUserById(masterId, &master); err != nil {
+		return http.StatusNotFound, err
+	}
+
+	if err := s.UserRepository.SetSchedule(masterId, schedule); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// CheckAvailability returns the reasons why the master cannot take the request at its datetime
+func (s ScheduleService) CheckAvailability(masterId bson.ObjectID, request *models.Request) ([]string, error) {
+	if request.DateTime.IsZero() {
+		return nil, nil
+	}
+
+	var master models.User
+	if err := s.UserRepository.GetUserById(masterId, &master); err != nil {
+		return nil, err
+	}
+
+	start := request.DateTime
+	end := start.Add(s.JobDuration(request.Category))
+	var conflicts []string
+
+	from, to, ok := s.workingHours(s.scheduleOf(&master), start)
+	if !ok {
+		conflicts = append(conflicts, fmt.Sprintf("%s is a day off", start.In(s.Config.Location).Format(time.DateOnly)))
+	} else if start.Before(from) || end.After(to) {
+		conflicts = append(conflicts, fmt.Sprintf("visit %s-%s is outside working hours %s-%s",
+			s.clock(start), s.clock(end), s.clock(from), s.clock(to)))
+	}
+
+	if master.Schedule != nil {
+		for _, slot := range master.Schedule.Blocked {
+			if slot.Overlaps(start, end) {
+				conflicts = append(conflicts, fmt.Sprintf("time is blocked %s-%s %s", s.clock(slot.From), s.clock(slot.To), slot.Reason))
+			}
+		}
+	}
+
+	busy, err := s.busy(masterId, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range busy {
+		if item.Id == request.Id {
+			continue
+		}
+		conflicts = append(conflicts, fmt.Sprintf("overlaps with request %s at %s", item.Id.Hex(), s.clock(item.DateTime)))
+	}
+
+	return conflicts, nil
+}
+
+// FreeSlots returns free intervals of the day that are not shorter than duration,
+// the duration of the category is used if it is given.
+// :date - format 2006-01-02 in the company timezone
+func (s ScheduleService) FreeSlots(masterId bson.ObjectID, date string, categoryId *bson.ObjectID, duration time.Duration) (int, *[]models.TimeSlot, error) {
+	day, err := time.ParseInLocation(time.DateOnly, date, s.Config.Location)
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.New("Invalid date")
+	}
+	if categoryId != nil {
+		var category models.Category
+		if err := s.CategoryRepository.FindById(categoryId, &category); err != nil {
+			return http.StatusNotFound, nil, err
+		}
+		duration = s.JobDuration(&category)
+	}
+	if duration <= 0 {
+		duration = s.Config.DefaultJobDuration
+	}
+
+	var master models.User
+	if err := s.UserRepository.GetUserById(masterId, &master); err != nil {
+		return http.StatusNotFound, nil, err
+	}
+
+	slots := []models.TimeSlot{}
+	from, to, ok := s.workingHours(s.scheduleOf(&master), day)
+	if !ok {
+		return http.StatusOK, &slots, nil
+	}
+
+	var taken []models.TimeSlot
+	if master.Schedule != nil {
+		taken = append(taken, master.Schedule.Blocked...)
+	}
+	busy, err := s.busy(masterId, from, to)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	for _, item := range busy {
+		taken = append(taken, models.TimeSlot{From: item.DateTime, To: item.DateTime.Add(s.JobDuration(item.Category))})
+	}
+	sort.Slice(taken, func(i, j int) bool { return taken[i].From.Before(taken[j].From) })
+
+	cursor := from
+	for _, slot := range taken {
+		if !slot.Overlaps(cursor, to) {
+			continue
+		}
+		if slot.From.Sub(cursor) >= duration {
+			slots = append(slots, models.TimeSlot{From: cursor, To: slot.From})
+		}
+		if slot.To.After(cursor) {
+			cursor = slot.To
+		}
+	}
+	if to.Sub(cursor) >= duration {
+		slots = append(slots, models.TimeSlot{From: cursor, To: to})
+	}
+
+	return http.StatusOK, &slots, nil
+}
+
+func (s ScheduleService) JobDuration(category *models.Category) time.Duration {
+	if category != nil && category.Duration > 0 {
+		return time.Duration(category.Duration) * time.Minute
+	}
+	return s.Config.DefaultJobDuration
+}
+
+// busy returns active requests of the master that overlap [from, to)
+func (s ScheduleService) busy(masterId bson.ObjectID, from time.Time, to time.Time) ([]models.Request, error) {
+	// a visit that started the day before can still overlap
+	var requests []models.Request
+	if err := s.RequestRepository.GetByWorker(masterId, from.Add(-24*time.Hour), to, &requests); err != nil {
+		return nil, err
+	}
+
+	var res []models.Request
+	for _, item := range requests {
+		end := item.DateTime.Add(s.JobDuration(item.Category))
+		if item.DateTime.Before(to) && from.Before(end) {
+			res = append(res, item)
+		}
+	}
+	return res, nil
+}
+
+func (s ScheduleService) scheduleOf(master *models.User) *models.Schedule {
+	if master.Schedule != nil && len(master.Schedule.WorkingHours) > 0 {
+		return master.Schedule
+	}
+
+	schedule := models.Schedule{}
+	if master.Schedule != nil {
+		schedule = *master.Schedule
+	}
+	for weekday := 0; weekday < 7; weekday++ {
+		schedule.WorkingHours = append(schedule.WorkingHours, models.WorkingHours{
+			Weekday: weekday,
+			Start:   s.Config.DefaultWorkStart,
+			End:     s.Config.DefaultWorkEnd,
+		})
+	}
+	return &schedule
+}
+
+// workingHours returns the working interval of the day that contains t, ok is false on a day off
+func (s ScheduleService) workingHours(schedule *models.Schedule, t time.Time) (time.Time, time.Time, bool) {
+	local := t.In(s.Config.Location)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, s.Config.Location)
+
+	for _, dayOff := range schedule.DaysOff {
+		if dayOff == day.Format(time.DateOnly) {
+			return time.Time{}, time.Time{}, false
+		}
+	}
+
+	for _, hours := range schedule.WorkingHours {
+		if hours.Weekday != int(day.Weekday()) {
+			continue
+		}
+		start, err1 := time.Parse("15:04", hours.Start)
+		end, err2 := time.Parse("15:04", hours.End)
+		if err1 != nil || err2 != nil {
+			return time.Time{}, time.Time{}, false
+		}
+		from := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, s.Config.Location)
+		to := time.Date(day.Year(), day.Month(), day.Day(), end.Hour(), end.Minute(), 0, 0, s.Config.Location)
+		return from, to, true
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+func (s ScheduleService) clock(t time.Time) string {
+	return t.In(s.Config.Location).Format("15:04")
+}
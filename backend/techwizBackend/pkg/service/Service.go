@@ -3,13 +3,25 @@ package service
 type (
 	// General service
 	Service struct {
-		Authorization    IAuthService
-		UserService      IUserService
-		RequestService   IRequestService
-		CategoryService  ICategoryService
-		ChatService      IChatService
-		MessageService   IMessageService
-		StatisticService IStatisticService
+		Authorization       IAuthService
+		UserService         IUserService
+		RequestService      IRequestService
+		CategoryService     ICategoryService
+		ChatService         IChatService
+		MessageService      IMessageService
+		StatisticService    IStatisticService
+		QuoteService        IQuoteService
+		DocumentService     IDocumentService
+		ReviewService       IReviewService
+		TierService         ITierService
+		ScheduleService     IScheduleService
+		GeoService          IGeoService
+		RouteService        IRouteService
+		SlaService          ISlaService
+		JobService          IJobService
+		NotificationService INotificationService
+		WebhookService      IWebhookService
+		ApiKeyService       IApiKeyService
 	}
 )
 
@@ -21,14 +33,38 @@ func NewServices(
 	chatService IChatService,
 	messageService IMessageService,
 	statisticService IStatisticService,
+	quoteService IQuoteService,
+	documentService IDocumentService,
+	reviewService IReviewService,
+	tierService ITierService,
+	scheduleService IScheduleService,
+	geoService IGeoService,
+	routeService IRouteService,
+	slaService ISlaService,
+	jobService IJobService,
+	notificationService INotificationService,
+	webhookService IWebhookService,
+	apiKeyService IApiKeyService,
 ) *Service {
 	return &Service{
-		Authorization:    authService,
-		UserService:      userService,
-		RequestService:   requestService,
-		CategoryService:  categoryService,
-		ChatService:      chatService,
-		MessageService:   messageService,
-		StatisticService: statisticService,
+		Authorization:       authService,
+		UserService:         userService,
+		RequestService:      requestService,
+		CategoryService:     categoryService,
+		ChatService:         chatService,
+		MessageService:      messageService,
+		StatisticService:    statisticService,
+		QuoteService:        quoteService,
+		DocumentService:     documentService,
+		ReviewService:       reviewService,
+		TierService:         tierService,
+		ScheduleService:     scheduleService,
+		GeoService:          geoService,
+		RouteService:        routeService,
+		SlaService:          slaService,
+		JobService:          jobService,
+		NotificationService: notificationService,
+		WebhookService:      webhookService,
+		ApiKeyService:       apiKeyService,
 	}
 }
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"techwizBackend/pkg/config"
+	"techwizBackend/pkg/models"
+	"techwizBackend/pkg/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type (
+	ISlaService interface {
+		CreatePolicy(policy *models.SlaPolicy) (int, error)
+		GetPolicies() *[]models.SlaPolicy
+		RemovePolicy(id bson.ObjectID) (int, error)
+		Check() (int, *[]models.Request)
+		CheckJob(job *models.Job) error
+	}
+
+	SlaService struct {
+		SlaRepository       repository.ISlaRepository
+		RequestRepository   repository.IRequestRepository
+		UserRepository      repository.IUserRepository
+		NotificationService INotificationService
+		Config              *config.Config
+	}
+)
+
+func NewSlaService(
+	slaRepository repository.ISlaRepository,
+	requestRepository repository.IRequestRepository,
+	userRepository repository.IUserRepository,
+	notificationService INotificationService,
+	cfg *config.Config,
+) *SlaService {
+	return &SlaService{
+		SlaRepository:       slaRepository,
+		RequestRepository:   requestRepository,
+		UserRepository:      userRepository,
+		NotificationService: notificationService,
+		Config:              cfg,
+	}
+}
+
+func (s *SlaService) CreatePolicy(policy *models.SlaPolicy) (int, error) {
+	if policy.Status < 1 || policy.Status > 7 {
+		return http.StatusBadRequest, errors.New("Invalid status code")
+	}
+	if policy.Minutes <= 0 {
+		return http.StatusBadRequest, errors.New("Minutes must be positive")
+	}
+
+	policy.Id = nil
+	if err := s.SlaRepository.Create(policy); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusCreated, nil
+}
+
+func (s *SlaService) GetPolicies() *[]models.SlaPolicy {
+	var policies []models.SlaPolicy
+	if err := s.SlaRepository.Get(&policies); err != nil {
+		return &[]models.SlaPolicy{}
+	}
+	return &policies
+}
+
+func (s *SlaService) RemovePolicy(id bson.ObjectID) (int, error) {
+	if err := s.SlaRepository.Remove(id); err != nil {
+		return http.StatusNotFound, err
+	}
+	return http.StatusOK, nil
+}
+
+// JobSlaCheck is the recurring job of the SLA check
+const JobSlaCheck = "sla.check"
+
+func (s *SlaService) CheckJob(*models.Job) error {
+	if status, _ := s.Check(); status != http.StatusOK {
+		return errors.New("SLA check failed")
+	}
+	return nil
+}
+
+// Check flags the requests that stay in the status longer than allowed,
+// notifies the dispatchers and returns the new breaches
+func (s *SlaService) Check() (int, *[]models.Request) {
+	policies := *s.GetPolicies()
+
+	// statuses with the default limits are checked always
+	codes := map[int]bool{1: true, 2: true}
+	for _, policy := range policies {
+		codes[policy.Status] = true
+	}
+	var list []int
+	for code := range codes {
+		list = append(list, code)
+	}
+
+	var requests []models.Request
+	if err := s.RequestRepository.GetUnflagged(list, &requests); err != nil {
+		log.Println(err)
+		return http.StatusInternalServerError, &[]models.Request{}
+	}
+
+	now := time.Now()
+	breached := []models.Request{}
+	for _, request := range requests {
+		limit, policyId, ok := s.limit(&request, policies)
+		if !ok {
+			continue
+		}
+
+		since := request.StatusAt
+		if since.IsZero() {
+			since = request.CreatedAt
+		}
+		deadline := since.Add(limit)
+		if now.Before(deadline) {
+			continue
+		}
+
+		breach := models.SlaBreach{
+			Status:     request.Status.Code,
+			Since:      since,
+			Deadline:   deadline,
+			BreachedAt: now,
+			PolicyId:   policyId,
+		}
+		if err := s.RequestRepository.FlagSla(request.Id, &breach); err != nil {
+			continue
+		}
+		request.Sla = &breach
+		breached = append(breached, request)
+	}
+
+	if len(breached) > 0 {
+		s.escalate(breached)
+	}
+	return http.StatusOK, &breached
+}
+
+// limit returns the limit of the most specific matching policy or the default one.
+// Premium requests without own policy get the limit multiplied by SlaPremiumFactor
+func (s *SlaService) limit(request *models.Request, policies []models.SlaPolicy) (time.Duration, *bson.ObjectID, bool) {
+	var best *models.SlaPolicy
+	for i, policy := range policies {
+		if policy.Matches(request) && (best == nil || policy.Specificity() > best.Specificity()) {
+			best = &policies[i]
+		}
+	}
+
+	var limit time.Duration
+	var policyId *bson.ObjectID
+	if best != nil {
+		limit = time.Duration(best.Minutes) * time.Minute
+		policyId = best.Id
+	} else {
+		switch request.Status.Code {
+		case 1:
+			limit = s.Config.SlaWaiting
+		case 2:
+			limit = s.Config.SlaAppointed
+		default:
+			return 0, nil, false
+		}
+	}
+
+	if request.Premium && (best == nil || best.Premium == nil) && s.Config.SlaPremiumFactor > 0 {
+		limit = time.Duration(float64(limit) * s.Config.SlaPremiumFactor)
+	}
+	return limit, policyId, true
+}
+
+// escalate notifies all dispatchers and admins
+func (s *SlaService) escalate(requests []models.Request) {
+	var users []models.User
+	if err := s.UserRepository.GetUsers(&users); err != nil {
+		log.Println(err)
+		return
+	}
+	var dispatchers []bson.ObjectID
+	for _, user := range users {
+		if user.IsDispatcher() && !user.Dismissed {
+			dispatchers = append(dispatchers, user.Id)
+		}
+	}
+
+	for _, request := range requests {
+		s.NotificationService.Notify(dispatchers, models.Notification{
+			Kind:      models.NotifySlaBreach,
+			Text:      fmt.Sprintf("Заявка %s в статусе %d дольше допустимого", request.Id.Hex(), request.Sla.Status),
+			RequestId: &request.Id,
+			Data:      map[string]any{"sla": request.Sla},
+		})
+	}
+}
@@ -0,0 +1,348 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"techwizBackend/pkg/config"
+	"techwizBackend/pkg/models"
+	"techwizBackend/pkg/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type (
+	ITierService interface {
+		Evaluate() (int, *[]models.TierDecision)
+		GetDecisions(masterId *bson.ObjectID, status string) (int, *[]models.TierDecision)
+		Apply(id bson.ObjectID, userId bson.ObjectID) (int, error)
+		Reject(id bson.ObjectID, userId bson.ObjectID) (int, error)
+		Override(masterId bson.ObjectID, status string, userId bson.ObjectID) (int, error)
+		Unlock(masterId bson.ObjectID, userId bson.ObjectID) (int, error)
+		EvaluateJob(job *models.Job) error
+	}
+
+	TierService struct {
+		TierRepository    repository.ITierRepository
+		UserRepository    repository.IUserRepository
+		RequestRepository repository.IRequestRepository
+		Config            *config.Config
+	}
+)
+
+func NewTierService(
+	tierRepository repository.ITierRepository,
+	userRepository repository.IUserRepository,
+	requestRepository repository.IRequestRepository,
+	cfg *config.Config,
+) *TierService {
+	return &TierService{
+		TierRepository:    tierRepository,
+		UserRepository:    userRepository,
+		RequestRepository: requestRepository,
+		Config:            cfg,
+	}
+}
+
+// JobTierEvaluate is the recurring job of the tier evaluation
+const JobTierEvaluate = "tier.evaluate"
+
+func (s TierService) EvaluateJob(*models.Job) error {
+	if status, _ := s.Evaluate(); status != http.StatusOK {
+		return errors.New("tier evaluation failed")
+	}
+	return nil
+}
+
+// Evaluate compares every master with the thresholds and returns the new decisions
+func (s TierService) Evaluate() (int, *[]models.TierDecision) {
+	var masters []models.User
+	if err := s.UserRepository.GetMasters(&masters); err != nil {
+		log.Println(err)
+		return http.StatusInternalServerError, &[]models.TierDecision{}
+	}
+
+	decisions := []models.TierDecision{}
+	for _, master := range masters {
+		if master.Dismissed || master.TierLocked || master.Permission != models.Master {
+			continue
+		}
+
+		decision, err := s.evaluate(&master)
+		if err != nil {
+			log.Printf("tier evaluation of %s: %s", master.Id.Hex(), err)
+			continue
+		}
+		if decision != nil {
+			decisions = append(decisions, *decision)
+		}
+	}
+
+	return http.StatusOK, &decisions
+}
+
+func (s TierService) evaluate(master *models.User) (*models.TierDecision, error) {
+	metrics, err := s.metrics(master)
+	if err != nil {
+		return nil, err
+	}
+
+	current := master.Status
+	if !master.IsValid() {
+		current = models.Default
+	}
+
+	target := models.Default
+	if ok, _ := meetsTier(metrics, s.Config.PremiumTier, current != models.Premium); ok {
+		target = models.Premium
+	} else if ok, _ := meetsTier(metrics, s.Config.SeniorTier, current == models.Default); ok {
+		target = models.Senior
+	}
+
+	// an older proposal is not valid anymore
+	if target == current {
+		return nil, s.TierRepository.Supersede(master.Id)
+	}
+
+	// promotion is explained by the passed thresholds of the new tier,
+	// demotion by the failed thresholds of the current one
+	var reasons []string
+	if tierRank(target) > tierRank(current) {
+		_, reasons = meetsTier(metrics, s.threshold(target), true)
+	} else {
+		_, reasons = meetsTier(metrics, s.threshold(current), false)
+	}
+
+	var pending models.TierDecision
+	if err := s.TierRepository.GetPending(master.Id, &pending); err == nil && pending.To == target {
+		return nil, nil
+	}
+
+	// the admin has already declined this tier, it is proposed again after the cooldown
+	var rejected []models.TierDecision
+	filter := bson.M{
+		"master_id":  master.Id,
+		"to":         target,
+		"status":     models.TierRejected,
+		"decided_at": bson.M{"$gte": time.Now().Add(-s.Config.TierCooldown)},
+	}
+	if err := s.TierRepository.Get(filter, &rejected); err != nil {
+		return nil, err
+	}
+	if len(rejected) > 0 {
+		return nil, nil
+	}
+
+	if err := s.TierRepository.Supersede(master.Id); err != nil {
+		return nil, err
+	}
+
+	decision := models.TierDecision{
+		MasterId:  master.Id,
+		From:      current,
+		To:        target,
+		Reasons:   reasons,
+		Metrics:   metrics,
+		Status:    models.TierProposed,
+		Source:    models.TierByEvaluation,
+		CreatedAt: time.Now(),
+	}
+
+	if s.Config.TierAutoApply {
+		if err := s.applyTier(master.Id, target); err != nil {
+			return nil, err
+		}
+		decision.Status = models.TierApplied
+		decision.DecidedAt = &decision.CreatedAt
+	}
+
+	if err := s.TierRepository.Create(&decision); err != nil {
+		return nil, err
+	}
+	return &decision, nil
+}
+
+func (s TierService) metrics(master *models.User) (*models.TierMetrics, error) {
+	counts, err := s.RequestRepository.CountByWorker(master.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := models.TierMetrics{
+		Completed:   counts[4],
+		Canceled:    counts[6],
+		Rating:      master.Rating,
+		RatingCount: master.RatingCount,
+		TenureDays:  int(time.Since(master.Id.Timestamp()).Hours() / 24),
+	}
+	for _, count := range counts {
+		metrics.Total += count
+	}
+	if metrics.Total > 0 {
+		metrics.CancelRate = float64(metrics.Canceled) / float64(metrics.Total)
+	}
+	return &metrics, nil
+}
+
+func (s TierService) threshold(tier string) config.TierThreshold {
+	if tier == models.Premium {
+		return s.Config.PremiumTier
+	}
+	return s.Config.SeniorTier
+}
+
+// meetsTier checks every threshold and explains the result.
+// Rating with too few reviews fails a promotion but does not demote the master
+func meetsTier(m *models.TierMetrics, t config.TierThreshold, promotion bool) (bool, []string) {
+	ok := true
+	var reasons []string
+	check := func(pass bool, format string, args ...any) {
+		if !pass {
+			ok = false
+		}
+		if pass == promotion {
+			reasons = append(reasons, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(m.Completed >= t.Completed, "completed requests %d, required %d", m.Completed, t.Completed)
+	check(m.CancelRate <= t.CancelRate, "cancellation rate %.2f, allowed %.2f", m.CancelRate, t.CancelRate)
+	check(m.TenureDays >= t.TenureDays, "tenure %d days, required %d", m.TenureDays, t.TenureDays)
+	if m.RatingCount >= t.MinReviews {
+		check(m.Rating >= t.Rating, "rating %.2f, required %.2f", m.Rating, t.Rating)
+	} else if promotion {
+		check(false, "reviews %d, required %d", m.RatingCount, t.MinReviews)
+	}
+	return ok, reasons
+}
+
+func tierRank(tier string) int {
+	switch tier {
+	case models.Premium:
+		return 2
+	case models.Senior:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (s TierService) applyTier(masterId bson.ObjectID, tier string) error {
+	if tier == models.Default {
+		return s.UserRepository.RemoveStatus(masterId)
+	}
+	return s.UserRepository.ChangeStatus(masterId, tier)
+}
+
+func (s TierService) GetDecisions(masterId *bson.ObjectID, status string) (int, *[]models.TierDecision) {
+	filter := bson.M{}
+	if masterId != nil {
+		filter["master_id"] = *masterId
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	var decisions []models.TierDecision
+	if err := s.TierRepository.Get(filter, &decisions); err != nil {
+		return http.StatusBadRequest, &[]models.TierDecision{}
+	}
+	return http.StatusOK, &decisions
+}
+
+func (s TierService) Apply(id bson.ObjectID, userId bson.ObjectID) (int, error) {
+	return s.decide(id, userId, true)
+}
+
+func (s TierService) Reject(id bson.ObjectID, userId bson.ObjectID) (int, error) {
+	return s.decide(id, userId, false)
+}
+
+func (s TierService) decide(id bson.ObjectID, userId bson.ObjectID, apply bool) (int, error) {
+	if status, err := checkAdmin(s.UserRepository, userId, "change the tier"); err != nil {
+		return status, err
+	}
+
+	var decision models.TierDecision
+	if err := s.TierRepository.GetById(id, &decision); err != nil {
+		return http.StatusNotFound, err
+	}
+
+	now := time.Now()
+	decision.DecidedBy = &userId
+	decision.DecidedAt = &now
+	decision.Status = models.TierRejected
+	if apply {
+		decision.Status = models.TierApplied
+	}
+
+	if err := s.TierRepository.Decide(&decision); err != nil {
+		return http.StatusConflict, err
+	}
+
+	if apply {
+		if err := s.applyTier(decision.MasterId, decision.To); err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// Override sets the tier by hand and excludes the master from the evaluation until Unlock
+func (s TierService) Override(masterId bson.ObjectID, status string, userId bson.ObjectID) (int, error) {
+	if code, err := checkAdmin(s.UserRepository, userId, "change the tier"); err != nil {
+		return code, err
+	}
+
+	master := models.User{Status: status}
+	if !master.IsValid() {
+		return http.StatusBadRequest, errors.New("Invalid status")
+	}
+
+	if err := s.UserRepository.GetUserById(masterId, &master); err != nil {
+		return http.StatusNotFound, err
+	}
+
+	if err := s.applyTier(masterId, status); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	if err := s.UserRepository.SetTierLocked(masterId, true); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	if err := s.TierRepository.Supersede(masterId); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	now := time.Now()
+	decision := models.TierDecision{
+		MasterId:  masterId,
+		From:      master.Status,
+		To:        status,
+		Status:    models.TierApplied,
+		Source:    models.TierByAdmin,
+		DecidedBy: &userId,
+		CreatedAt: now,
+		DecidedAt: &now,
+	}
+	if err := s.TierRepository.Create(&decision); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return http.StatusOK, nil
+}
+
+// Unlock returns the master to the evaluation
+func (s TierService) Unlock(masterId bson.ObjectID, userId bson.ObjectID) (int, error) {
+	if status, err := checkAdmin(s.UserRepository, userId, "change the tier"); err != nil {
+		return status, err
+	}
+
+	if err := s.UserRepository.SetTierLocked(masterId, false); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
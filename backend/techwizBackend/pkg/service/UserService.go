@@ -2,17 +2,21 @@ package service
 
 import (
 	"errors"
+	"fmt"
 	"go.mongodb.org/mongo-driver/v2/bson"
+	"log"
 	"net/http"
+	"sort"
 	"techwizBackend/pkg/models"
 	"techwizBackend/pkg/repository"
+	"time"
 )
 
 type (
 	IUserService interface {
 		GetUser(id string, user *models.User, statusCode *int) error
 		GetUsers() *[]models.User
-		GetMasters() *[]models.User
+		GetMasters(sortBy string) *[]models.User
 		ChangePassword(user *models.User, statusCode *int) error
 		ChangePermission(id bson.ObjectID, permissionOld string, permissionNew string, statusCode *int) error
 		AddCategory(idUser bson.ObjectID, idCategory bson.ObjectID) (int, error)
@@ -20,12 +24,17 @@ type (
 		ChangeStatus(id bson.ObjectID, event string, status string) (int, error)
 		DismissUser(id bson.ObjectID) (int, error)
 		UpdateUser(idUser bson.ObjectID, user *models.User) error
+		GetOnline(ids []bson.ObjectID) []models.Presence
+		SetLastSeen(id bson.ObjectID, at time.Time)
+		SetPresence(presence IPresence)
 	}
 
 	UserService struct {
-		UserRepository    repository.IUserRepository
-		ChatRepository    repository.IChatRepository
-		RequestRepository repository.IRequestRepository
+		UserRepository      repository.IUserRepository
+		ChatRepository      repository.IChatRepository
+		RequestRepository   repository.IRequestRepository
+		NotificationService INotificationService
+		Presence            IPresence
 	}
 )
 
@@ -33,11 +42,42 @@ func NewUserService(
 	userRepository repository.IUserRepository,
 	chatRepository repository.IChatRepository,
 	requestRepository repository.IRequestRepository,
+	notificationService INotificationService,
 ) *UserService {
 	return &UserService{
-		UserRepository:    userRepository,
-		ChatRepository:    chatRepository,
-		RequestRepository: requestRepository,
+		UserRepository:      userRepository,
+		ChatRepository:      chatRepository,
+		RequestRepository:   requestRepository,
+		NotificationService: notificationService,
+	}
+}
+
+func (s *UserService) SetPresence(presence IPresence) {
+	s.Presence = presence
+}
+
+// GetOnline returns the status of the users in the order of ids, offline users have the last seen time
+func (s UserService) GetOnline(ids []bson.ObjectID) []models.Presence {
+	lastSeen, err := s.UserRepository.GetLastSeen(ids)
+	if err != nil {
+		log.Println(err)
+	}
+
+	result := make([]models.Presence, len(ids))
+	for i, id := range ids {
+		result[i] = models.Presence{UserId: id}
+		if s.Presence != nil && s.Presence.IsOnline(id) {
+			result[i].Online = true
+		} else if at, ok := lastSeen[id]; ok {
+			result[i].LastSeen = &at
+		}
+	}
+	return result
+}
+
+func (s UserService) SetLastSeen(id bson.ObjectID, at time.Time) {
+	if err := s.UserRepository.SetLastSeen(id, at); err != nil {
+		log.Println(err)
 	}
 }
 
@@ -120,11 +160,20 @@ func (s UserService) GetUsers() *[]models.User {
 	return &users
 }
 
-func (s UserService) GetMasters() *[]models.User {
+// GetMasters returns masters, sortBy "rating" puts the best rated masters first
+func (s UserService) GetMasters(sortBy string) *[]models.User {
 	var users []models.User
 	if err := s.UserRepository.GetMasters(&users); err != nil {
 		return &[]models.User{}
 	}
+	if sortBy == "rating" {
+		sort.SliceStable(users, func(i, j int) bool {
+			if users[i].Rating != users[j].Rating {
+				return users[i].Rating > users[j].Rating
+			}
+			return users[i].RatingCount > users[j].RatingCount
+		})
+	}
 	return &users
 }
 
@@ -153,6 +202,11 @@ func (s UserService) AddCategory(idUser bson.ObjectID, idCategory bson.ObjectID)
 		return http.StatusInternalServerError, err
 	}
 
+	s.NotificationService.Notify([]bson.ObjectID{idUser}, models.Notification{
+		Kind: models.NotifyCategoryAdded,
+		Text: fmt.Sprintf("Вам добавлена категория %s", chat.Name),
+		Data: map[string]any{"category_id": idCategory.Hex()},
+	})
 	return http.StatusOK, nil
 }
 
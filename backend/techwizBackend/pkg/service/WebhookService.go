@@ -0,0 +1,273 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"techwizBackend/pkg/config"
+	"techwizBackend/pkg/models"
+	"techwizBackend/pkg/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// JobWebhook sends a single delivery, retries are made by the job service
+const JobWebhook = "webhook.deliver"
+
+type (
+	IWebhookService interface {
+		Emit(event string, request *models.Request)
+		Create(userId bson.ObjectID, webhook *models.Webhook) (int, error)
+		GetWebhooks(userId bson.ObjectID) (int, *[]models.Webhook, error)
+		Remove(userId bson.ObjectID, id bson.ObjectID) (int, error)
+		GetDeliveries(userId bson.ObjectID, webhookId *bson.ObjectID, status string) (int, *[]models.WebhookDelivery, error)
+		Replay(userId bson.ObjectID, deliveryId bson.ObjectID) (int, error)
+		DeliverJob(job *models.Job) error
+	}
+
+	WebhookService struct {
+		WebhookRepository repository.IWebhookRepository
+		UserRepository    repository.IUserRepository
+		JobService        IJobService
+		Config            *config.Config
+		client            *http.Client
+	}
+)
+
+func NewWebhookService(
+	webhookRepository repository.IWebhookRepository,
+	userRepository repository.IUserRepository,
+	jobService IJobService,
+	cfg *config.Config,
+) *WebhookService {
+	return &WebhookService{
+		WebhookRepository: webhookRepository,
+		UserRepository:    userRepository,
+		JobService:        jobService,
+		Config:            cfg,
+		client:            &http.Client{Timeout: cfg.WebhookTimeout},
+	}
+}
+
+// Emit creates a delivery for every subscribed webhook, sending is asynchronous.
+// The request is sent as models.PartnerRequest, the worker only with id and name
+func (s WebhookService) Emit(event string, request *models.Request) {
+	var webhooks []models.Webhook
+	if err := s.WebhookRepository.GetActive(&webhooks); err != nil {
+		log.Println(err)
+		return
+	}
+
+	now := time.Now()
+	body, err := json.Marshal(map[string]any{
+		"event":      event,
+		"created_at": now,
+		"request":    models.NewPartnerRequest(request),
+	})
+	if err != nil {
+		log.Printf("failed to encode webhook %s: %s", event, err)
+		return
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.Accepts(event) {
+			continue
+		}
+
+		delivery := models.WebhookDelivery{
+			WebhookId: webhook.Id,
+			Event:     event,
+			Body:      string(body),
+			Status:    models.DeliveryPending,
+			CreatedAt: now,
+		}
+		if err := s.WebhookRepository.CreateDelivery(&delivery); err != nil {
+			log.Println(err)
+			continue
+		}
+		s.enqueue(delivery.Id)
+	}
+}
+
+func (s WebhookService) enqueue(deliveryId bson.ObjectID) {
+	payload := map[string]any{"delivery_id": deliveryId.Hex()}
+	if _, err := s.JobService.Enqueue(JobWebhook, "webhook:"+deliveryId.Hex(), payload, time.Now()); err != nil {
+		log.Printf("failed to enqueue delivery %s: %s", deliveryId.Hex(), err)
+	}
+}
+
+// DeliverJob posts the signed body, the delivery is dead after the last failed attempt
+func (s WebhookService) DeliverJob(job *models.Job) error {
+	id, _ := job.Payload["delivery_id"].(string)
+	deliveryId, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid delivery_id in payload")
+	}
+
+	var delivery models.WebhookDelivery
+	if err := s.WebhookRepository.GetDelivery(deliveryId, &delivery); err != nil {
+		return err
+	}
+	if delivery.Status != models.DeliveryPending {
+		return nil
+	}
+
+	var webhook models.Webhook
+	if err := s.WebhookRepository.GetById(delivery.WebhookId, &webhook); err != nil {
+		// the webhook is removed, nothing to retry
+		attempt := models.DeliveryAttempt{At: time.Now(), Error: err.Error()}
+		return s.WebhookRepository.AddAttempt(deliveryId, attempt, models.DeliveryDead)
+	}
+
+	attempt := s.send(&webhook, &delivery)
+	if attempt.Error == "" {
+		return s.WebhookRepository.AddAttempt(deliveryId, attempt, models.DeliveryDelivered)
+	}
+
+	status := models.DeliveryPending
+	if job.Attempts >= job.MaxAttempts {
+		status = models.DeliveryDead
+	}
+	if err := s.WebhookRepository.AddAttempt(deliveryId, attempt, status); err != nil {
+		log.Println(err)
+	}
+	return errors.New(attempt.Error)
+}
+
+// send signs "timestamp.body" with HMAC-SHA256 of the webhook secret
+func (s WebhookService) send(webhook *models.Webhook, delivery *models.WebhookDelivery) models.DeliveryAttempt {
+	start := time.Now()
+	attempt := models.DeliveryAttempt{At: start}
+	timestamp := strconv.FormatInt(start.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(webhook.Secret))
+	mac.Write([]byte(timestamp + "." + delivery.Body))
+
+	req, err := http.NewRequest(http.MethodPost, webhook.Url, bytes.NewBufferString(delivery.Body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Techpower-Event", delivery.Event)
+	req.Header.Set("X-Techpower-Delivery", delivery.Id.Hex())
+	req.Header.Set("X-Techpower-Timestamp", timestamp)
+	req.Header.Set("X-Techpower-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	res, err := s.client.Do(req)
+	attempt.Duration = time.Since(start).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	attempt.StatusCode = res.StatusCode
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		attempt.Error = fmt.Sprintf("unexpected status %d", res.StatusCode)
+	}
+	return attempt
+}
+
+// Create registers the webhook, the secret is generated if empty and returned only here
+func (s WebhookService) Create(userId bson.ObjectID, webhook *models.Webhook) (int, error) {
+	if status, err := checkAdmin(s.UserRepository, userId, "manage webhooks"); err != nil {
+		return status, err
+	}
+
+	u, err := url.Parse(webhook.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return http.StatusBadRequest, errors.New("Invalid url")
+	}
+	for _, event := range webhook.Events {
+		switch event {
+		case models.EventRequestCreated, models.EventRequestAttached, models.EventRequestStatus, models.EventRequestDone:
+		default:
+			return http.StatusBadRequest, fmt.Errorf("Unknown event %s", event)
+		}
+	}
+
+	if webhook.Secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return http.StatusInternalServerError, err
+		}
+		webhook.Secret = hex.EncodeToString(b)
+	}
+	webhook.Active = true
+	webhook.CreatedAt = time.Now()
+
+	if err := s.WebhookRepository.Create(webhook); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusCreated, nil
+}
+
+func (s WebhookService) GetWebhooks(userId bson.ObjectID) (int, *[]models.Webhook, error) {
+	if status, err := checkAdmin(s.UserRepository, userId, "manage webhooks"); err != nil {
+		return status, nil, err
+	}
+
+	webhooks := []models.Webhook{}
+	if err := s.WebhookRepository.Get(&webhooks); err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	return http.StatusOK, &webhooks, nil
+}
+
+func (s WebhookService) Remove(userId bson.ObjectID, id bson.ObjectID) (int, error) {
+	if status, err := checkAdmin(s.UserRepository, userId, "manage webhooks"); err != nil {
+		return status, err
+	}
+
+	if err := s.WebhookRepository.Remove(id); err != nil {
+		return http.StatusNotFound, err
+	}
+	return http.StatusOK, nil
+}
+
+// GetDeliveries returns the delivery log, status "dead" is the dead-letter list
+func (s WebhookService) GetDeliveries(userId bson.ObjectID, webhookId *bson.ObjectID, status string) (int, *[]models.WebhookDelivery, error) {
+	if status, err := checkAdmin(s.UserRepository, userId, "manage webhooks"); err != nil {
+		return status, nil, err
+	}
+
+	filter := bson.M{}
+	if webhookId != nil {
+		filter["webhook_id"] = *webhookId
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	deliveries := []models.WebhookDelivery{}
+	if err := s.WebhookRepository.GetDeliveries(filter, &deliveries); err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	return http.StatusOK, &deliveries, nil
+}
+
+// Replay sends the delivery again with a new series of attempts
+func (s WebhookService) Replay(userId bson.ObjectID, deliveryId bson.ObjectID) (int, error) {
+	if status, err := checkAdmin(s.UserRepository, userId, "manage webhooks"); err != nil {
+		return status, err
+	}
+
+	if err := s.WebhookRepository.ResetDelivery(deliveryId); err != nil {
+		return http.StatusNotFound, err
+	}
+	s.enqueue(deliveryId)
+	return http.StatusOK, nil
+}
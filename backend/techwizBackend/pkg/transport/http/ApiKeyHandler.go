@@ -0,0 +1,141 @@
+package http
+
+import (
+	"net/http"
+	"techwizBackend/pkg/models"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ----------------------------------
+//
+//	JSON {
+//		name "partner.ru"
+//		scopes ["request:create", "request:read"]
+//		rate_limit - requests per minute, optional
+//	}
+//
+// ----------------------------------
+func (h Handler) createApiKey(c echo.Context) error {
+	userId, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	var key models.ApiKey
+	if err := c.Bind(&key); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid request body"})
+	}
+
+	status, err := h.services.ApiKeyService.Create(userId, &key)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	// the key is shown only once
+	return c.JSON(status, key)
+}
+
+func (h Handler) getApiKeys(c echo.Context) error {
+	userId, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	status, keys, err := h.services.ApiKeyService.GetKeys(userId)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, map[string]*[]models.ApiKey{"keys": keys})
+}
+
+func (h Handler) revokeApiKey(c echo.Context) error {
+	id, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid id"})
+	}
+	userId, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	status, err := h.services.ApiKeyService.Revoke(userId, id)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, map[string]string{"status": "ok"})
+}
+
+// apiKey checks the X-Api-Key header of partner routes, the key is saved in the context
+func (h Handler) apiKey(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			status, key, err := h.services.ApiKeyService.Authenticate(c.Request().Header.Get("X-Api-Key"), scope)
+			if err != nil {
+				if status == http.StatusTooManyRequests {
+					c.Response().Header().Set("Retry-After", "60")
+				}
+				return c.JSON(status, map[string]string{"error": err.Error()})
+			}
+
+			c.Set("apiKey", key)
+			return next(c)
+		}
+	}
+}
+
+// body { full_name, phone_number, address, category_id, datetime, problem }, other fields are set by us
+func (h Handler) createPartnerRequest(c echo.Context) error {
+	key := c.Get("apiKey").(*models.ApiKey)
+
+	var input models.PartnerRequestInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid request body"})
+	}
+	request := input.Request()
+	request.SourceId = &key.Id
+
+	if status, err := h.services.RequestService.Create(&request); err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	response := map[string]any{"status": "Request created", "id": request.Id, "tracking_token": request.TrackingToken}
+	if len(request.Warnings) > 0 {
+		response["warnings"] = request.Warnings
+	}
+	return c.JSON(http.StatusCreated, response)
+}
+
+func (h Handler) getPartnerRequests(c echo.Context) error {
+	key := c.Get("apiKey").(*models.ApiKey)
+
+	requests := []models.PartnerRequest{}
+	for _, request := range *h.services.RequestService.GetBySource(key.Id) {
+		requests = append(requests, models.NewPartnerRequest(&request))
+	}
+	return c.JSON(http.StatusOK, map[string][]models.PartnerRequest{"requests": requests})
+}
+
+func (h Handler) getPartnerRequest(c echo.Context) error {
+	key := c.Get("apiKey").(*models.ApiKey)
+
+	id, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid id"})
+	}
+
+	var request models.Request
+	status, err := h.services.RequestService.GetRequest(id, &request)
+	// requests of other sources look the same as missing ones
+	if err != nil || request.SourceId == nil || *request.SourceId != key.Id {
+		if err == nil {
+			status = http.StatusNotFound
+		}
+		return c.JSON(status, map[string]string{"error": "Request not found"})
+	}
+
+	return c.JSON(http.StatusOK, models.NewPartnerRequest(&request))
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"net/http"
+	"strconv"
 	"techwizBackend/pkg/models"
 )
 
@@ -73,3 +74,21 @@ func (h Handler) removeCategory(c echo.Context) error {
 
 	return c.JSON(status, category)
 }
+
+func (h Handler) setCategoryDuration(c echo.Context) error {
+	id, err := bson.ObjectIDFromHex(c.QueryParam("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid id"})
+	}
+	minutes, err := strconv.Atoi(c.QueryParam("minutes"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid minutes"})
+	}
+
+	status, err := h.services.CategoryService.SetDuration(id, minutes)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, map[string]int{"duration": minutes})
+}
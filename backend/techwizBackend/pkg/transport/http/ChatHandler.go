@@ -2,7 +2,9 @@ package http
 
 import (
 	"net/http"
+	"strconv"
 	"techwizBackend/pkg/models"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"go.mongodb.org/mongo-driver/v2/bson"
@@ -83,6 +85,8 @@ func (h Handler) removeUserFromChat(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
 }
 
+// :before and :after - message id or time "2025-07-05T18:05:00Z", :limit - up to 200, 50 by default
+// example /message?id=686832fb6fd2db7bc42f0c63&before=686832fb6fd2db7bc42f0c70&limit=50
 func (h Handler) getMessageByChat(c echo.Context) error {
 	chat_id, err := bson.ObjectIDFromHex(c.QueryParam("id"))
 	if err != nil {
@@ -90,5 +94,134 @@ func (h Handler) getMessageByChat(c echo.Context) error {
 			"error": err.Error(),
 		})
 	}
-	return c.JSON(h.services.MessageService.GetMessageByChat(chat_id))
+
+	before, err := parseAnchor(c.QueryParam("before"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid before"})
+	}
+	after, err := parseAnchor(c.QueryParam("after"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid after"})
+	}
+	limit := 0
+	if c.QueryParam("limit") != "" {
+		if limit, err = strconv.Atoi(c.QueryParam("limit")); err != nil {
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid limit"})
+		}
+	}
+
+	status, page, err := h.services.MessageService.GetMessages(chat_id, before, after, limit)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(status, page)
+}
+
+// parseAnchor accepts a message id or a time
+func parseAnchor(value string) (*models.MessageAnchor, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if id, err := bson.ObjectIDFromHex(value); err == nil {
+		return &models.MessageAnchor{Id: &id}, nil
+	}
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &models.MessageAnchor{At: &at}, nil
+}
+
+// example /chat/686832fb6fd2db7bc42f0c63/read?user=686832fb6fd2db7bc42f0c65&message=686832fb6fd2db7bc42f0c70
+// without :message the chat is read up to the last message
+func (h Handler) readChat(c echo.Context) error {
+	idChat, err := bson.ObjectIDFromHex(c.Param("idChat"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid idChat"})
+	}
+	idUser, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	var messageId *bson.ObjectID
+	if c.QueryParam("message") != "" {
+		id, err := bson.ObjectIDFromHex(c.QueryParam("message"))
+		if err != nil {
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid message"})
+		}
+		messageId = &id
+	}
+
+	status, receipt, err := h.services.ChatService.MarkRead(idUser, idChat, messageId)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(status, receipt)
+}
+
+// ----------------------------------
+//
+//	JSON {
+//		text
+//	}
+//
+// ----------------------------------
+// example /message/686832fb6fd2db7bc42f0c70?user=686832fb6fd2db7bc42f0c65
+func (h Handler) editMessage(c echo.Context) error {
+	id, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid id"})
+	}
+	userId, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	var body struct {
+		Text string `json:"text"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid request body"})
+	}
+
+	status, message, err := h.services.MessageService.Edit(userId, id, body.Text)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(status, message)
+}
+
+func (h Handler) deleteMessage(c echo.Context) error {
+	id, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid id"})
+	}
+	userId, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	status, message, err := h.services.MessageService.Delete(userId, id)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(status, message)
+}
+
+func (h Handler) getMessageHistory(c echo.Context) error {
+	id, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid id"})
+	}
+	userId, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	status, edits, err := h.services.MessageService.GetHistory(userId, id)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(status, map[string][]models.MessageEdit{"edits": edits})
 }
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"techwizBackend/pkg/models"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func (h Handler) getDocuments(c echo.Context) error {
+	requestId, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid requestId"})
+	}
+
+	return c.JSON(h.services.DocumentService.GetByRequest(requestId))
+}
+
+// :kind - "invoice" || "act", invoice by default
+// example /request/686832fb6fd2db7bc42f0c63/document?kind=act
+func (h Handler) downloadDocument(c echo.Context) error {
+	requestId, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid requestId"})
+	}
+	kind := c.QueryParam("kind")
+	if kind == "" {
+		kind = models.Invoice
+	}
+
+	var document models.Document
+	status, err := h.services.DocumentService.Get(requestId, kind, &document)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", document.FileName))
+	return c.Blob(http.StatusOK, "application/pdf", document.Content)
+}
+
+// generateDocuments renders the documents again, e.g. after the template was changed
+func (h Handler) generateDocuments(c echo.Context) error {
+	requestId, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid requestId"})
+	}
+
+	status, err := h.services.DocumentService.Generate(requestId)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(h.services.DocumentService.GetByRequest(requestId))
+}
+
+func (h Handler) getDocumentTemplate(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.services.DocumentService.GetTemplate())
+}
+
+// ----------------------------------
+//
+//	JSON {
+//		company_name
+//		company_address
+//		company_phone
+//		company_details
+//		invoice_title "Счёт № {{.Number}} от {{.Date}}"
+//		act_title "Акт выполненных работ № {{.Number}} от {{.Date}}"
+//		footer
+//	}
+//
+// ----------------------------------
+func (h Handler) updateDocumentTemplate(c echo.Context) error {
+	var tmpl models.DocumentTemplate
+	if err := c.Bind(&tmpl); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid request body"})
+	}
+
+	status, err := h.services.DocumentService.UpdateTemplate(&tmpl)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, tmpl)
+}
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"techwizBackend/pkg/models"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type locationBody struct {
+	Address string   `json:"address"`
+	Lat     *float64 `json:"lat"`
+	Lng     *float64 `json:"lng"`
+}
+
+func (b locationBody) point() *models.Point {
+	if b.Lat == nil || b.Lng == nil {
+		return nil
+	}
+	return models.NewPoint(*b.Lat, *b.Lng)
+}
+
+// ----------------------------------
+//
+//	JSON {
+//		lat
+//		lng
+//	}
+//
+// ----------------------------------
+// an empty body geocodes the address of the request
+// example /request/686832fb6fd2db7bc42f0c63/location
+func (h Handler) locateRequest(c echo.Context) error {
+	id, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid id"})
+	}
+
+	var body locationBody
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid request body"})
+	}
+
+	status, location, err := h.services.GeoService.LocateRequest(id, body.point())
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, location)
+}
+
+// ----------------------------------
+//
+//	JSON {
+//		address || lat, lng
+//	}
+//
+// ----------------------------------
+// example /user/686832fb6fd2db7bc42f0c63/base
+func (h Handler) setMasterBase(c echo.Context) error {
+	id, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid id"})
+	}
+
+	var body locationBody
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid request body"})
+	}
+
+	status, base, err := h.services.GeoService.SetBase(id, body.Address, body.point())
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, base)
+}
+
+func (h Handler) getNearestMasters(c echo.Context) error {
+	id, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid id"})
+	}
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	status, masters, err := h.services.GeoService.NearestMasters(id, limit)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, map[string]*[]models.NearbyMaster{"masters": masters})
+}
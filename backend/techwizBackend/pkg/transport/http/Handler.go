@@ -1,6 +1,7 @@
 package http
 
 import (
+	"techwizBackend/pkg/models"
 	"techwizBackend/pkg/service"
 	"techwizBackend/pkg/transport/ws"
 
@@ -23,6 +24,9 @@ func New(e *echo.Echo, service *service.Service, websocketConn *ws.WebsocketConn
 	category.GET("", h.getCategory)       // DONE
 	category.PUT("", h.renameCategory)    // DONE
 	category.DELETE("", h.removeCategory) // DONE
+	// :minutes - estimated job duration
+	// example /category/duration?id=686832fb6fd2db7bc42f0c63&minutes=90
+	category.PATCH("/duration", h.setCategoryDuration)
 
 	user := e.Group("user")
 	user.PATCH("/changepassword", h.changePassword)                                       // DONE
@@ -30,34 +34,131 @@ func New(e *echo.Echo, service *service.Service, websocketConn *ws.WebsocketConn
 	user.PUT("/:id", h.updateUser)
 	user.GET("/:id", h.getUser)
 	user.GET("", h.getUsers)
-	user.GET("/masters", h.getMasters)                   // DONE
+	user.GET("/masters", h.getMasters) // DONE
+	user.GET("/online", h.getOnline)
 	user.PATCH("/category/add", h.addUserCategory)       // DONE
 	user.PATCH("/category/remove", h.removeUserCategory) // DONE
-	// :id - user id, :event - "add" || "remove", :status - null || "default" || "senior" || "premium"
-	// example /user/master?id=686832fb6fd2db7bc42f0c63&event=add&status=senior
+	// :id - user id, :event - "add" || "remove", :status - null || "default" || "senior" || "premium", :user - admin id for "add"
+	// example /user/master?id=686832fb6fd2db7bc42f0c63&event=add&status=senior&user=686832fb6fd2db7bc42f0c65
 	// example /user/master?id=686832fb6fd2db7bc42f0c63&event=remove
 	user.PATCH("/master", h.changeStatus)
+	// automatic tier evaluation, :event - "apply" || "reject", :user - admin id
+	// example /user/tier/decision/686832fb6fd2db7bc42f0c63?event=apply&user=686832fb6fd2db7bc42f0c65
+	// example /user/tier/unlock/686832fb6fd2db7bc42f0c63?user=686832fb6fd2db7bc42f0c65
+	user.POST("/tier/evaluate", h.evaluateTiers)
+	user.GET("/tier/decisions", h.getTierDecisions)
+	user.PATCH("/tier/decision/:id", h.decideTier)
+	user.PATCH("/tier/unlock/:id", h.unlockTier)
 	user.PATCH("/dismiss/:id", h.dismissUser)
+	user.GET("/:id/reviews", h.getMasterReviews)
+	user.GET("/:id/schedule", h.getSchedule)
+	user.PUT("/:id/schedule", h.updateSchedule)
+	// :date - 2006-01-02, :category - category id || :duration - minutes
+	// example /user/686832fb6fd2db7bc42f0c63/slots?date=2025-07-10&category=686832fb6fd2db7bc42f0c64
+	user.GET("/:id/slots", h.getFreeSlots)
+	user.PATCH("/:id/base", h.setMasterBase)
+	// suggested order of visits, :date - 2006-01-02
+	// example /user/686832fb6fd2db7bc42f0c63/route?date=2025-07-10
+	user.GET("/:id/route", h.getRoute)
+	user.POST("/:id/route", h.planRoute)
 
 	chat := e.Group("chat")
 	chat.POST("/create/:member1/:member2", h.createChat)  // DONE
 	chat.POST("/:idChat/:idUser", h.addUserToChat)        // DONE
 	chat.DELETE("/:idChat/:idUser", h.removeUserFromChat) // DONE
 	chat.GET("/:userId", h.getChats)                      // DONE
+	chat.PATCH("/:idChat/read", h.readChat)
 	//chat.GET("/:member1/:member2", h.getChatByMember)     // DONE
 
 	message := e.Group("message")
 	message.GET("", h.getMessageByChat)
+	// :user - the sender, the admin can delete any message and see its history
+	message.PATCH("/:id", h.editMessage)
+	message.DELETE("/:id", h.deleteMessage)
+	message.GET("/:id/history", h.getMessageHistory)
 
 	request := e.Group("request")
 	request.POST("", h.createRequest)
 	request.GET("", h.getRequests)
 	request.GET("/:id", h.getRequest)
+	// example /request/attach/686832fb6fd2db7bc42f0c63/686832fb6fd2db7bc42f0c65?force=true
 	request.PATCH("/attach/:requestId/:userId", h.attachMasterToRequest)
 	request.PATCH("", h.changeStatusRequest)
 	request.PATCH("/in_spot", h.requestInSpot)
+	// example /request/686832fb6fd2db7bc42f0c63/datetime?force=true
+	request.PATCH("/:id/datetime", h.changeDateTime)
+	// :user - attached master, body { lat, lng } is optional
+	// example /request/686832fb6fd2db7bc42f0c63/checkin?user=686832fb6fd2db7bc42f0c65
+	request.PATCH("/:id/checkin", h.checkIn)
+	request.PATCH("/:id/checkout", h.checkOut)
+	request.POST("/:id/quote", h.proposeQuote)
+	request.GET("/:id/quote", h.getQuotes)
+	request.PATCH("/:id/quote/approve", h.approveQuote)
+	request.PATCH("/:id/quote/reject", h.rejectQuote)
+	request.GET("/:id/documents", h.getDocuments)
+	request.GET("/:id/document", h.downloadDocument)
+	request.POST("/:id/document", h.generateDocuments)
+	request.POST("/:id/review", h.createReview)
+	request.GET("/:id/review", h.getReview)
+	request.PATCH("/:id/location", h.locateRequest)
+	// example /request/686832fb6fd2db7bc42f0c63/masters?limit=5
+	request.GET("/:id/masters", h.getNearestMasters)
+
+	document := e.Group("document")
+	document.GET("/template", h.getDocumentTemplate)
+	document.PUT("/template", h.updateDocumentTemplate)
 	//request.PUT("/:id", h.changeRequest) // in work
 
+	// customer routes, the tracking token is used instead of authorization
+	public := e.Group("public")
+	public.GET("/request/:token/quote", h.getQuotesByToken)
+	public.PATCH("/request/:token/quote/approve", h.approveQuoteByToken)
+	public.PATCH("/request/:token/quote/reject", h.rejectQuoteByToken)
+	public.POST("/request/:token/review", h.createReviewByToken)
+
+	// limits of statuses, :status - request status code, :category and :premium are optional
+	sla := e.Group("sla")
+	sla.GET("/policy", h.getSlaPolicies)
+	sla.POST("/policy", h.createSlaPolicy)
+	sla.DELETE("/policy/:id", h.removeSlaPolicy)
+	sla.POST("/check", h.checkSla)
+
+	// :user - recipient id
+	// example /notification?user=686832fb6fd2db7bc42f0c63&unread=true
+	notification := e.Group("notification")
+	notification.GET("", h.getNotifications)
+	notification.GET("/unread", h.getUnreadCount)
+	notification.PATCH("/read", h.markNotificationsRead)
+
+	// background jobs, :user - admin id, :status and :type are optional filters
+	// example /job?user=686832fb6fd2db7bc42f0c65&status=failed
+	job := e.Group("job")
+	job.GET("", h.getJobs)
+	job.PATCH("/:id/retry", h.retryJob)
+	job.PATCH("/:id/cancel", h.cancelJob)
+
+	// partner webhooks, :user - admin id, :status - pending, delivered or dead
+	// example /webhook/deliveries?user=686832fb6fd2db7bc42f0c65&status=dead
+	webhook := e.Group("webhook")
+	webhook.POST("", h.createWebhook)
+	webhook.GET("", h.getWebhooks)
+	webhook.DELETE("/:id", h.removeWebhook)
+	webhook.GET("/:id/deliveries", h.getDeliveries)
+	webhook.GET("/deliveries", h.getDeliveries)
+	webhook.POST("/delivery/:id/replay", h.replayDelivery)
+
+	// api keys of lead sources, :user - admin id
+	apikey := e.Group("apikey")
+	apikey.POST("", h.createApiKey)
+	apikey.GET("", h.getApiKeys)
+	apikey.DELETE("/:id", h.revokeApiKey)
+
+	// partner routes, the key is sent in the X-Api-Key header
+	partner := e.Group("partner")
+	partner.POST("/request", h.createPartnerRequest, h.apiKey(models.ScopeRequestCreate))
+	partner.GET("/request", h.getPartnerRequests, h.apiKey(models.ScopeRequestRead))
+	partner.GET("/request/:id", h.getPartnerRequest, h.apiKey(models.ScopeRequestRead))
+
 	statistics := e.Group("statistics")
 	statistics.GET("", h.getStatistics)
 
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"net/http"
+	"techwizBackend/pkg/models"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func (h Handler) getJobs(c echo.Context) error {
+	userId, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	status, jobs, err := h.services.JobService.GetJobs(userId, c.QueryParam("status"), c.QueryParam("type"))
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, map[string]*[]models.Job{"jobs": jobs})
+}
+
+func (h Handler) retryJob(c echo.Context) error {
+	id, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid id"})
+	}
+	userId, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	status, err := h.services.JobService.Retry(id, userId)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, map[string]string{"status": "ok"})
+}
+
+func (h Handler) cancelJob(c echo.Context) error {
+	id, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid id"})
+	}
+	userId, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	status, err := h.services.JobService.Cancel(id, userId)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, map[string]string{"status": "ok"})
+}
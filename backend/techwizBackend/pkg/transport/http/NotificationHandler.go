@@ -0,0 +1,63 @@
+package http
+
+import (
+	"net/http"
+	"techwizBackend/pkg/models"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func (h Handler) getNotifications(c echo.Context) error {
+	userId, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	unread := c.QueryParam("unread") == "true"
+	status, notifications := h.services.NotificationService.GetNotifications(userId, unread)
+	return c.JSON(status, map[string]*[]models.Notification{"notifications": notifications})
+}
+
+func (h Handler) getUnreadCount(c echo.Context) error {
+	userId, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	status, count, err := h.services.NotificationService.UnreadCount(userId)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, map[string]int64{"unread": count})
+}
+
+// ----------------------------------
+//
+//	JSON {
+//		ids [] - all notifications are marked if empty
+//	}
+//
+// ----------------------------------
+// example /notification/read?user=686832fb6fd2db7bc42f0c63
+func (h Handler) markNotificationsRead(c echo.Context) error {
+	userId, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	var body struct {
+		Ids []bson.ObjectID `json:"ids"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid request body"})
+	}
+
+	status, count, err := h.services.NotificationService.MarkRead(userId, body.Ids)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, map[string]int64{"marked": count})
+}
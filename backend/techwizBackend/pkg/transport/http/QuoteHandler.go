@@ -0,0 +1,117 @@
+package http
+
+import (
+	"net/http"
+	"techwizBackend/pkg/models"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ----------------------------------
+//
+//	JSON {
+//		items [{ type "labour" || "parts", description, quantity, unit_price }]
+//		total - ignored if items are set
+//		bail
+//		reason
+//	}
+//
+// ----------------------------------
+// example /request/686832fb6fd2db7bc42f0c63/quote?user=686832fb6fd2db7bc42f0c64
+func (h Handler) proposeQuote(c echo.Context) error {
+	requestId, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid requestId"})
+	}
+	userId, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	var quote models.Quote
+	if err := c.Bind(&quote); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid request body"})
+	}
+
+	status, err := h.services.QuoteService.Propose(requestId, userId, &quote)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, quote)
+}
+
+func (h Handler) getQuotes(c echo.Context) error {
+	requestId, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid requestId"})
+	}
+
+	return c.JSON(h.services.QuoteService.GetQuotes(requestId))
+}
+
+// example /request/686832fb6fd2db7bc42f0c63/quote/approve?user=686832fb6fd2db7bc42f0c65&comment=ok
+func (h Handler) approveQuote(c echo.Context) error {
+	return h.decideQuote(c, true)
+}
+
+func (h Handler) rejectQuote(c echo.Context) error {
+	return h.decideQuote(c, false)
+}
+
+func (h Handler) decideQuote(c echo.Context, approve bool) error {
+	requestId, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid requestId"})
+	}
+	userId, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	var status int
+	if approve {
+		status, err = h.services.QuoteService.Approve(requestId, userId, c.QueryParam("comment"))
+	} else {
+		status, err = h.services.QuoteService.Reject(requestId, userId, c.QueryParam("comment"))
+	}
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, map[string]string{"id": requestId.Hex()})
+}
+
+func (h Handler) getQuotesByToken(c echo.Context) error {
+	return c.JSON(h.services.QuoteService.GetQuotesByToken(c.Param("token")))
+}
+
+// example /public/request/0f3c.../quote/approve?comment=ok
+func (h Handler) approveQuoteByToken(c echo.Context) error {
+	return h.decideQuoteByToken(c, true)
+}
+
+func (h Handler) rejectQuoteByToken(c echo.Context) error {
+	return h.decideQuoteByToken(c, false)
+}
+
+func (h Handler) decideQuoteByToken(c echo.Context, approve bool) error {
+	token := c.Param("token")
+	if token == "" {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid token"})
+	}
+
+	var status int
+	var err error
+	if approve {
+		status, err = h.services.QuoteService.ApproveByToken(token, c.QueryParam("comment"))
+	} else {
+		status, err = h.services.QuoteService.RejectByToken(token, c.QueryParam("comment"))
+	}
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, map[string]string{"status": "ok"})
+}
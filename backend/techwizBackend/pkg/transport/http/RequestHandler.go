@@ -28,16 +28,23 @@ func (h Handler) createRequest(c echo.Context) error {
 			map[string]string{"error": "Invalid request body"},
 		)
 	}
+	// the source is set only by partner api keys
+	request.SourceId = nil
 
 	if status, err := h.services.RequestService.Create(&request); err != nil {
 		return c.JSON(status, map[string]string{"error": err.Error()})
 	}
 
+	if len(request.Warnings) > 0 {
+		return c.JSON(http.StatusCreated, map[string]any{"status": "Request created", "warnings": request.Warnings})
+	}
 	return c.JSON(http.StatusCreated, map[string]string{"status": "Request created"})
 }
 
+// example /request?sla=breached
 func (h Handler) getRequests(c echo.Context) error {
-	return c.JSON(http.StatusOK, map[string]*[]models.Request{"requests": h.services.RequestService.GetRequests()})
+	breached := c.QueryParam("sla") == "breached"
+	return c.JSON(http.StatusOK, map[string]*[]models.Request{"requests": h.services.RequestService.GetRequests(breached)})
 }
 
 func (h Handler) attachMasterToRequest(c echo.Context) error {
@@ -51,8 +58,11 @@ func (h Handler) attachMasterToRequest(c echo.Context) error {
 		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
 	}
 
+	// force=true attaches the master in spite of schedule conflicts
+	force := c.QueryParam("force") == "true"
+
 	var request models.Request
-	status, err := h.services.RequestService.AttachMaster(requestId, userId, &request)
+	status, err := h.services.RequestService.AttachMaster(requestId, userId, force, &request)
 	if err != nil {
 		return c.JSON(status, map[string]string{"error": err.Error()})
 	}
@@ -133,3 +143,85 @@ func (h Handler) requestInSpot(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
 }
+
+// ----------------------------------
+//
+//	JSON {
+//		lat
+//		lng
+//	}
+//
+// ----------------------------------
+func (h Handler) checkIn(c echo.Context) error {
+	requestId, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid requestId"})
+	}
+	masterId, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	var body locationBody
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid request body"})
+	}
+
+	status, event, err := h.services.RequestService.CheckIn(requestId, masterId, body.point())
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, event)
+}
+
+func (h Handler) checkOut(c echo.Context) error {
+	requestId, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid requestId"})
+	}
+	masterId, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	var body locationBody
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid request body"})
+	}
+
+	status, visit, err := h.services.RequestService.CheckOut(requestId, masterId, body.point())
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, visit)
+}
+
+// ----------------------------------
+//
+//	JSON {
+//		datetime "2025-07-10T14:00:00+03:00"
+//	}
+//
+// ----------------------------------
+func (h Handler) changeDateTime(c echo.Context) error {
+	id, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid requestId"})
+	}
+
+	var body models.Request
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid request body"})
+	}
+	force := c.QueryParam("force") == "true"
+
+	var request models.Request
+	status, err := h.services.RequestService.ChangeDateTime(id, body.DateTime, force, &request)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, request)
+}
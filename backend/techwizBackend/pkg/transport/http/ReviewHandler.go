@@ -0,0 +1,79 @@
+package http
+
+import (
+	"net/http"
+	"techwizBackend/pkg/models"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ----------------------------------
+//
+//	JSON {
+//		rating 1-5
+//		comment
+//	}
+//
+// ----------------------------------
+// example /request/686832fb6fd2db7bc42f0c63/review?user=686832fb6fd2db7bc42f0c65
+func (h Handler) createReview(c echo.Context) error {
+	requestId, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid requestId"})
+	}
+	userId, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	var review models.Review
+	if err := c.Bind(&review); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid request body"})
+	}
+
+	status, err := h.services.ReviewService.Create(requestId, userId, &review)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, review)
+}
+
+func (h Handler) createReviewByToken(c echo.Context) error {
+	var review models.Review
+	if err := c.Bind(&review); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid request body"})
+	}
+
+	status, err := h.services.ReviewService.CreateByToken(c.Param("token"), &review)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, map[string]string{"status": "ok"})
+}
+
+func (h Handler) getReview(c echo.Context) error {
+	requestId, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid requestId"})
+	}
+
+	var review models.Review
+	status, err := h.services.ReviewService.GetByRequest(requestId, &review)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, review)
+}
+
+func (h Handler) getMasterReviews(c echo.Context) error {
+	id, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid id"})
+	}
+
+	return c.JSON(h.services.ReviewService.GetByMaster(id))
+}
@@ -0,0 +1,37 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func (h Handler) getRoute(c echo.Context) error {
+	masterId, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	status, route, err := h.services.RouteService.GetRoute(masterId, c.QueryParam("date"))
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, route)
+}
+
+// planRoute builds the route again, e.g. after the coordinates of a request were changed
+func (h Handler) planRoute(c echo.Context) error {
+	masterId, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	status, route, err := h.services.RouteService.Plan(masterId, c.QueryParam("date"))
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, route)
+}
@@ -0,0 +1,86 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"techwizBackend/pkg/models"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func (h Handler) getSchedule(c echo.Context) error {
+	masterId, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	status, schedule, err := h.services.ScheduleService.GetSchedule(masterId)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, schedule)
+}
+
+// ----------------------------------
+//
+//	JSON {
+//		working_hours [{ weekday 0-6 (0 - sunday), start "09:00", end "18:00" }]
+//		days_off ["2025-07-10"]
+//		blocked [{ from, to, reason }]
+//	}
+//
+// ----------------------------------
+// example /user/686832fb6fd2db7bc42f0c63/schedule
+func (h Handler) updateSchedule(c echo.Context) error {
+	masterId, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	var schedule models.Schedule
+	if err := c.Bind(&schedule); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid request body"})
+	}
+
+	status, err := h.services.ScheduleService.UpdateSchedule(masterId, &schedule)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, schedule)
+}
+
+func (h Handler) getFreeSlots(c echo.Context) error {
+	masterId, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	var categoryId *bson.ObjectID
+	if c.QueryParam("category") != "" {
+		id, err := bson.ObjectIDFromHex(c.QueryParam("category"))
+		if err != nil {
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid categoryId"})
+		}
+		categoryId = &id
+	}
+
+	var duration time.Duration
+	if c.QueryParam("duration") != "" {
+		minutes, err := strconv.Atoi(c.QueryParam("duration"))
+		if err != nil {
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid duration"})
+		}
+		duration = time.Duration(minutes) * time.Minute
+	}
+
+	status, slots, err := h.services.ScheduleService.FreeSlots(masterId, c.QueryParam("date"), categoryId, duration)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, map[string]*[]models.TimeSlot{"slots": slots})
+}
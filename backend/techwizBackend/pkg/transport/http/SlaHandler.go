@@ -0,0 +1,58 @@
+package http
+
+import (
+	"net/http"
+	"techwizBackend/pkg/models"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func (h Handler) getSlaPolicies(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.services.SlaService.GetPolicies())
+}
+
+// ----------------------------------
+//
+//	JSON {
+//		name
+//		status 1-7
+//		category_id
+//		premium
+//		minutes
+//	}
+//
+// ----------------------------------
+func (h Handler) createSlaPolicy(c echo.Context) error {
+	var policy models.SlaPolicy
+	if err := c.Bind(&policy); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid request body"})
+	}
+
+	status, err := h.services.SlaService.CreatePolicy(&policy)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, policy)
+}
+
+func (h Handler) removeSlaPolicy(c echo.Context) error {
+	id, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid id"})
+	}
+
+	status, err := h.services.SlaService.RemovePolicy(id)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, map[string]string{"status": "ok"})
+}
+
+// checkSla runs the check now instead of waiting for the background one
+func (h Handler) checkSla(c echo.Context) error {
+	status, requests := h.services.SlaService.Check()
+	return c.JSON(status, map[string]*[]models.Request{"requests": requests})
+}
@@ -0,0 +1,71 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func (h Handler) evaluateTiers(c echo.Context) error {
+	return c.JSON(h.services.TierService.Evaluate())
+}
+
+// :master - null || master id, :status - null || "proposed" || "applied" || "rejected" || "superseded"
+// example /user/tier/decisions?master=686832fb6fd2db7bc42f0c63&status=proposed
+func (h Handler) getTierDecisions(c echo.Context) error {
+	var masterId *bson.ObjectID
+	if c.QueryParam("master") != "" {
+		id, err := bson.ObjectIDFromHex(c.QueryParam("master"))
+		if err != nil {
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid master"})
+		}
+		masterId = &id
+	}
+
+	return c.JSON(h.services.TierService.GetDecisions(masterId, c.QueryParam("status")))
+}
+
+func (h Handler) decideTier(c echo.Context) error {
+	id, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid id"})
+	}
+	userId, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	var status int
+	switch c.QueryParam("event") {
+	case "apply":
+		status, err = h.services.TierService.Apply(id, userId)
+	case "reject":
+		status, err = h.services.TierService.Reject(id, userId)
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid event"})
+	}
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, map[string]string{"status": "ok"})
+}
+
+func (h Handler) unlockTier(c echo.Context) error {
+	id, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid id"})
+	}
+	userId, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	status, err := h.services.TierService.Unlock(id, userId)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, map[string]string{"status": "ok"})
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"net/http"
+	"strings"
 	"techwizBackend/pkg/models"
 )
 
@@ -83,10 +84,12 @@ func (h Handler) getUsers(c echo.Context) error {
 	)
 }
 
+// :sort - null || "rating"
+// example /user/masters?sort=rating
 func (h Handler) getMasters(c echo.Context) error {
 	return c.JSON(
 		http.StatusOK,
-		h.services.UserService.GetMasters(),
+		h.services.UserService.GetMasters(c.QueryParam("sort")),
 	)
 }
 
@@ -145,7 +148,17 @@ func (h Handler) changeStatus(c echo.Context) error {
 	event := c.QueryParam("event")
 	status := c.QueryParam("status")
 
-	code, err := h.services.UserService.ChangeStatus(id, event, status)
+	var code int
+	if event == "add" {
+		// manual tier is recorded and excluded from the evaluation
+		userId, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+		if err != nil {
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+		}
+		code, err = h.services.TierService.Override(id, status, userId)
+	} else {
+		code, err = h.services.UserService.ChangeStatus(id, event, status)
+	}
 	if err != nil {
 		return c.JSON(code, map[string]string{"error": err.Error()})
 	}
@@ -187,3 +200,23 @@ func (h Handler) updateUser(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
 }
+
+// example /user/online?ids=686832fb6fd2db7bc42f0c63,686832fb6fd2db7bc42f0c65
+func (h Handler) getOnline(c echo.Context) error {
+	var ids []bson.ObjectID
+	for _, item := range strings.Split(c.QueryParam("ids"), ",") {
+		if item == "" {
+			continue
+		}
+		id, err := bson.ObjectIDFromHex(strings.TrimSpace(item))
+		if err != nil {
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid id " + item})
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ids are required"})
+	}
+
+	return c.JSON(http.StatusOK, map[string][]models.Presence{"users": h.services.UserService.GetOnline(ids)})
+}
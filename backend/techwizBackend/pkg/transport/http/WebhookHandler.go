@@ -0,0 +1,112 @@
+package http
+
+import (
+	"net/http"
+	"techwizBackend/pkg/models"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ----------------------------------
+//
+//	JSON {
+//		url
+//		events ["request.created", "request.attached", "request.status_changed", "request.completed"]
+//		secret - optional, generated if empty
+//	}
+//
+// ----------------------------------
+func (h Handler) createWebhook(c echo.Context) error {
+	userId, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	var webhook models.Webhook
+	if err := c.Bind(&webhook); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid request body"})
+	}
+
+	status, err := h.services.WebhookService.Create(userId, &webhook)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	// the secret is shown only once
+	return c.JSON(status, webhook)
+}
+
+func (h Handler) getWebhooks(c echo.Context) error {
+	userId, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	status, webhooks, err := h.services.WebhookService.GetWebhooks(userId)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, map[string]*[]models.Webhook{"webhooks": webhooks})
+}
+
+func (h Handler) removeWebhook(c echo.Context) error {
+	id, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid id"})
+	}
+	userId, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	status, err := h.services.WebhookService.Remove(userId, id)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, map[string]string{"status": "ok"})
+}
+
+// getDeliveries returns the delivery log of all webhooks or of the webhook from :id
+func (h Handler) getDeliveries(c echo.Context) error {
+	userId, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	var webhookId *bson.ObjectID
+	if c.Param("id") != "" {
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid id"})
+		}
+		webhookId = &id
+	}
+
+	status, deliveries, err := h.services.WebhookService.GetDeliveries(userId, webhookId, c.QueryParam("status"))
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, map[string]*[]models.WebhookDelivery{"deliveries": deliveries})
+}
+
+func (h Handler) replayDelivery(c echo.Context) error {
+	id, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid id"})
+	}
+	userId, err := bson.ObjectIDFromHex(c.QueryParam("user"))
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "Invalid userId"})
+	}
+
+	status, err := h.services.WebhookService.Replay(userId, id)
+	if err != nil {
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(status, map[string]string{"status": "ok"})
+}
@@ -0,0 +1,75 @@
+package ws
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"os"
+	"techwizBackend/pkg/config"
+	"techwizBackend/pkg/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Bus fans out the events to the hubs of all backend instances, the hub of the instance included
+type Bus interface {
+	// Publish sends notifications, presence and other events
+	Publish(event Event) error
+	// PublishMessage sends the saved chat message as message.new to the recipients
+	PublishMessage(message *models.Message, recipients []bson.ObjectID) error
+	// Run delivers the events of all instances, it blocks
+	Run(deliver func(Event))
+}
+
+// Recipients returns the members of the chat of the message
+type Recipients func(message *models.Message) []bson.ObjectID
+
+// NewBus selects the bus by the config, "memory" works only with a single instance
+func NewBus(cfg *config.Config, db *mongo.Client, recipients Recipients) Bus {
+	switch cfg.WsBus {
+	case "mongo":
+		return NewMongoBus(db, recipients)
+	case "memory", "":
+		return NewMemoryBus()
+	default:
+		log.Printf("ws: unknown bus %q, the memory bus is used", cfg.WsBus)
+		return NewMemoryBus()
+	}
+}
+
+// MemoryBus passes the events to the hub of this process
+type MemoryBus struct {
+	events chan Event
+}
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{events: make(chan Event, 256)}
+}
+
+func (b *MemoryBus) Publish(event Event) error {
+	b.events <- event
+	return nil
+}
+
+func (b *MemoryBus) PublishMessage(message *models.Message, recipients []bson.ObjectID) error {
+	envelope, err := models.NewEnvelope(models.WsMessageNew, "", message)
+	if err != nil {
+		return err
+	}
+	return b.Publish(Event{UserIds: recipients, Envelope: envelope, MessageId: &message.Id})
+}
+
+func (b *MemoryBus) Run(deliver func(Event)) {
+	for event := range b.events {
+		deliver(event)
+	}
+}
+
+// newInstanceId identifies the instance in the events of the bus
+func newInstanceId() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return host + "-" + hex.EncodeToString(b)
+}
@@ -0,0 +1,143 @@
+package ws
+
+import (
+	"log"
+	"sync"
+	"techwizBackend/pkg/models"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Client is a connection of the user. Only writePump writes to the connection,
+// the others put envelopes in the send queue
+type Client struct {
+	UserId  bson.ObjectID
+	conn    *websocket.Conn
+	send    chan frame
+	written func(userId bson.ObjectID, frames []frame) // called for the chat messages after they are written
+
+	mu     sync.Mutex
+	closed bool
+
+	typing map[bson.ObjectID]time.Time // last typing event of the chat, used only by the reader
+}
+
+// frame is an envelope in the send queue. A chat message counts as delivered only after the write,
+// a message queued to a half-open connection is sent again by sync
+type frame struct {
+	envelope  models.Envelope
+	messageId *bson.ObjectID
+	cursor    *models.MessageAnchor // set on the last message of a sync, the delivery cursor is moved to it
+}
+
+// maxWrittenBatch limits the chat messages whose delivery is recorded at once
+const maxWrittenBatch = 100
+
+func newClient(userId bson.ObjectID, conn *websocket.Conn, buffer int, written func(bson.ObjectID, []frame)) *Client {
+	return &Client{
+		UserId:  userId,
+		conn:    conn,
+		send:    make(chan frame, buffer),
+		written: written,
+		typing:  make(map[bson.ObjectID]time.Time),
+	}
+}
+
+// allowTyping passes one typing event of the chat per interval
+func (c *Client) allowTyping(chatId bson.ObjectID, interval time.Duration) bool {
+	if last, ok := c.typing[chatId]; ok && time.Since(last) < interval {
+		return false
+	}
+	c.typing[chatId] = time.Now()
+	return true
+}
+
+func (c *Client) resetTyping(chatId bson.ObjectID) {
+	delete(c.typing, chatId)
+}
+
+// queue never blocks, false means the queue is full or the client is closed
+func (c *Client) queue(envelope models.Envelope) bool {
+	return c.push(frame{envelope: envelope})
+}
+
+// queueMessage queues message.new, the delivery is recorded by the writer
+func (c *Client) queueMessage(envelope models.Envelope, messageId bson.ObjectID, cursor *models.MessageAnchor) bool {
+	return c.push(frame{envelope: envelope, messageId: &messageId, cursor: cursor})
+}
+
+func (c *Client) push(f frame) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return false
+	}
+	select {
+	case c.send <- f:
+		return true
+	default:
+		return false
+	}
+}
+
+// close stops the writer, the reader stops on the closed connection
+func (c *Client) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+	c.closed = true
+	close(c.send)
+}
+
+// writePump writes the queue and pings the client, a failed write closes the connection.
+// The delivery of the written chat messages is recorded in batches when the queue is drained
+func (c *Client) writePump(writeWait time.Duration, pingInterval time.Duration) {
+	ticker := time.NewTicker(pingInterval)
+	var written []frame
+	flush := func() {
+		if len(written) > 0 && c.written != nil {
+			go c.written(c.UserId, written)
+		}
+		written = nil
+	}
+	defer func() {
+		flush()
+		ticker.Stop()
+		if err := c.conn.Close(); err != nil {
+			log.Printf("Не удалось разорвать соединение: %v", err)
+		}
+	}()
+
+	for {
+		select {
+		case f, ok := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				_ = c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			if err := c.conn.WriteJSON(f.envelope); err != nil {
+				log.Printf("write error to client %s: %s", c.UserId.Hex(), err)
+				return
+			}
+			if f.messageId != nil {
+				written = append(written, f)
+			}
+			if len(c.send) == 0 || len(written) >= maxWrittenBatch {
+				flush()
+			}
+
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				log.Printf("ping error to client %s: %s", c.UserId.Hex(), err)
+				return
+			}
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package ws
 
 import (
+	"encoding/json"
 	"log"
 	"net/http"
 	"techwizBackend/pkg/models"
+	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
@@ -30,12 +32,6 @@ var upgrader = websocket.Upgrader{
 func (wsConn *WebsocketConnection) Ws(c echo.Context) error {
 	id, _ := bson.ObjectIDFromHex(c.QueryParam("id"))
 	conn, err := upgrader.Upgrade(c.Response().Writer, c.Request(), nil)
-	defer func() {
-		if err := conn.Close(); err != nil {
-			log.Printf("Не удалось разорвать соединение: %v", err)
-		}
-	}()
-
 	if err != nil {
 		log.Println(err)
 		return c.JSON(
@@ -44,26 +40,45 @@ func (wsConn *WebsocketConnection) Ws(c echo.Context) error {
 		)
 	}
 
-	// Add new user connection to Hub
-	user := models.User{Id: id, Conn: conn}
-	wsConn.Hub.Add <- user
+	// Add new user connection to Hub, the connection is closed by the writer of the client
+	cfg := wsConn.Hub.config
+	client := newClient(id, conn, cfg.WsSendBuffer, wsConn.Hub.written)
+	wsConn.Hub.Add <- client
+	// the ping must come before the read deadline
+	pingInterval := cfg.WsPingInterval
+	if pingInterval >= cfg.WsPongWait {
+		pingInterval = cfg.WsPongWait * 9 / 10
+	}
+	go client.writePump(cfg.WsWriteTimeout, pingInterval)
+	defer func() {
+		wsConn.Hub.Remove <- client
+	}()
+
+	// a half-open connection fails the read after the pong wait
+	conn.SetReadLimit(cfg.WsMaxMessage)
+	_ = conn.SetReadDeadline(time.Now().Add(cfg.WsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(cfg.WsPongWait))
+	})
 
 	for {
-		var message models.Message
-		if err := conn.ReadJSON(&message); err != nil {
-			// If the error is a connection closure
+		_, data, err := conn.ReadMessage()
+		if err != nil {
 			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
 				log.Printf("Клиент %s закрыл соединение", id)
-				wsConn.Hub.Remove <- user.Id
-				return nil
 			} else {
 				log.Printf("read error from client %s: %s", id, err)
-				continue
 			}
+			// the connection is broken after a read error
+			return nil
 		}
+		_ = conn.SetReadDeadline(time.Now().Add(cfg.WsPongWait))
 
-		message.SenderId = user.Id
-		message.Conn = user.Conn
-		wsConn.Hub.Broadcast <- message
+		var envelope models.Envelope
+		if err := json.Unmarshal(data, &envelope); err != nil || envelope.Type == "" {
+			wsConn.Hub.reply(client, models.NewErrorEnvelope(envelope.Id, models.WsErrBadEnvelope, "expected {v, type, id, payload}"))
+			continue
+		}
+		wsConn.Hub.dispatch(client, envelope)
 	}
 }
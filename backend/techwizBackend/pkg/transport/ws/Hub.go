@@ -1,63 +1,422 @@
 package ws
 
 import (
+	"encoding/json"
 	"log"
+	"net/http"
+	"techwizBackend/pkg/config"
 	"techwizBackend/pkg/models"
 	"techwizBackend/pkg/service"
+	"time"
 
-	"github.com/gorilla/websocket"
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
+// Hub keeps the registry of the clients. The loop never waits for the database or a connection:
+// envelopes are read and handled in the goroutine of the connection, written by the writer of the client.
+// Events go through the bus, so users connected to other instances get them too
 type Hub struct {
-	services  *service.Service
-	Clients   map[bson.ObjectID]*websocket.Conn
-	Broadcast chan models.Message
-	Add       chan models.User
-	Remove    chan bson.ObjectID
+	services *service.Service
+	config   *config.Config
+	bus      Bus
+	instance string
+	presence *presence
+	Clients  map[bson.ObjectID]map[*Client]struct{} // every tab and device of the user
+	Add      chan *Client
+	Remove   chan *Client
+	Events   chan Event
+	outbox   chan Event // published one after another, so the events keep their order
 }
 
-func NewHub(services *service.Service) *Hub {
+// Event is sent to the connected users
+type Event struct {
+	Origin    string          `bson:"origin"` // instance that published the event
+	UserIds   []bson.ObjectID `bson:"user_ids"`
+	Envelope  models.Envelope `bson:"envelope"`
+	MessageId *bson.ObjectID  `bson:"message_id,omitempty"` // set for message.new to track the delivery
+	CreatedAt time.Time       `bson:"created_at"`
+}
+
+func NewHub(services *service.Service, cfg *config.Config, bus Bus) *Hub {
 	return &Hub{
-		services:  services,
-		Clients:   make(map[bson.ObjectID]*websocket.Conn),
-		Broadcast: make(chan models.Message, 50),
-		Add:       make(chan models.User, 50),
-		Remove:    make(chan bson.ObjectID, 50),
+		services: services,
+		config:   cfg,
+		bus:      bus,
+		instance: newInstanceId(),
+		presence: newPresence(3 * cfg.WsPresenceSync),
+		Clients:  make(map[bson.ObjectID]map[*Client]struct{}),
+		Add:      make(chan *Client, 50),
+		Remove:   make(chan *Client, 50),
+		Events:   make(chan Event, 256),
+		outbox:   make(chan Event, 1024),
 	}
 }
 
+// Push implements service.IPusher, users that are offline are skipped
+func (h *Hub) Push(userIds []bson.ObjectID, eventType string, payload any) {
+	envelope, err := models.NewEnvelope(eventType, "", payload)
+	if err != nil {
+		log.Printf("failed to encode %s: %s", eventType, err)
+		return
+	}
+	h.publish(Event{UserIds: userIds, Envelope: envelope})
+}
+
+// IsOnline tells whether the user is connected to any instance
+func (h *Hub) IsOnline(userId bson.ObjectID) bool {
+	return h.presence.isOnline(userId)
+}
+
 func (h *Hub) Run() {
+	go h.bus.Run(func(event Event) {
+		h.Events <- event
+	})
+	go h.publishLoop()
+
+	sync := time.NewTicker(h.config.WsPresenceSync)
+	defer sync.Stop()
+
 	for {
 		select {
-		case user := <-h.Add:
-			h.Clients[user.Id] = user.Conn
-			log.Printf("клиент %s подключён | всего клиентов: %d", user.Id.Hex(), len(h.Clients))
-
-		case id := <-h.Remove:
-			if _, ok := h.Clients[id]; ok {
-				delete(h.Clients, id)
-				log.Printf("клиент отключён | всего клиентов: %d", len(h.Clients))
+		case client := <-h.Add:
+			if _, ok := h.Clients[client.UserId]; !ok {
+				h.Clients[client.UserId] = make(map[*Client]struct{})
+				h.setOnline(client.UserId, true)
 			}
+			h.Clients[client.UserId][client] = struct{}{}
+			log.Printf("клиент %s подключён | соединений: %d | всего клиентов: %d",
+				client.UserId.Hex(), len(h.Clients[client.UserId]), len(h.Clients))
+
+		case client := <-h.Remove:
+			h.remove(client)
+
+		case event := <-h.Events:
+			h.deliver(event)
+
+		case <-sync.C:
+			userIds := make([]bson.ObjectID, 0, len(h.Clients))
+			for id := range h.Clients {
+				userIds = append(userIds, id)
+			}
+			h.publishPresence(busPresenceSync, userIds, true)
+		}
+	}
+}
+
+// deliver writes the event of the bus to the local connections of the users
+func (h *Hub) deliver(event Event) {
+	switch event.Envelope.Type {
+	case busPresence, busPresenceSync:
+		if event.Origin == h.instance {
+			return
+		}
+		var payload presencePayload
+		if err := json.Unmarshal(event.Envelope.Payload, &payload); err != nil {
+			log.Printf("bus: bad presence from %s: %s", event.Origin, err)
+			return
+		}
+		if event.Envelope.Type == busPresenceSync {
+			h.presence.sync(event.Origin, payload.UserIds)
+		} else {
+			h.presence.setRemote(event.Origin, payload.UserIds, payload.Online)
+		}
+		return
+	}
 
-		case message := <-h.Broadcast:
-			if err := h.services.MessageService.Save(&message); err != nil {
-				message.Conn.WriteJSON(map[string]string{"error": err.Error()})
-				continue
+	// users that are offline get the message with sync on reconnect
+	for _, item := range event.UserIds {
+		for client := range h.Clients[item] {
+			var queued bool
+			if event.MessageId != nil {
+				queued = client.queueMessage(event.Envelope, *event.MessageId, nil)
+			} else {
+				queued = client.queue(event.Envelope)
 			}
-			recipients := h.services.ChatService.GetRecipient(&message)
-			for _, item := range recipients {
-				log.Println(message)
-				if conn, ok := h.Clients[item]; ok {
-					if err := conn.WriteJSON(&message); err != nil {
-						log.Printf("write error: %s", err)
-						if err := h.Clients[item].Close(); err != nil {
-							log.Printf("Не удалось разорвать соединение: %v", err)
-						}
-						delete(h.Clients, item)
-					}
-				}
+			// the slow consumer is dropped instead of stalling the others
+			if !queued {
+				log.Printf("send queue of client %s is full, dropping the connection", item.Hex())
+				h.remove(client)
 			}
 		}
 	}
 }
+
+// written records the delivery of the messages written by the client with a single update,
+// it is called by the writer
+func (h *Hub) written(userId bson.ObjectID, frames []frame) {
+	messageIds := make([]bson.ObjectID, 0, len(frames))
+	var cursor *models.MessageAnchor
+	for _, f := range frames {
+		messageIds = append(messageIds, *f.messageId)
+		if f.cursor != nil {
+			cursor = f.cursor
+		}
+	}
+
+	if err := h.services.MessageService.MarkDelivered(messageIds, []bson.ObjectID{userId}); err != nil {
+		log.Printf("failed to mark delivered: %s", err)
+	}
+	// everything up to the last message of the sync is written, with more the rest stays after the cursor
+	if cursor != nil {
+		if err := h.services.MessageService.SetCursor(userId, *cursor); err != nil {
+			log.Printf("failed to move delivery cursor: %s", err)
+		}
+	}
+}
+
+// remove closes only this connection, the user is removed with the last one
+func (h *Hub) remove(client *Client) {
+	if connections, ok := h.Clients[client.UserId]; ok {
+		if _, ok := connections[client]; ok {
+			delete(connections, client)
+			if len(connections) == 0 {
+				delete(h.Clients, client.UserId)
+				h.setOnline(client.UserId, false)
+			}
+			log.Printf("клиент отключён | всего клиентов: %d", len(h.Clients))
+		}
+	}
+	client.close()
+}
+
+func (h *Hub) setOnline(userId bson.ObjectID, online bool) {
+	h.presence.setLocal(userId, online)
+	h.publishPresence(busPresence, []bson.ObjectID{userId}, online)
+	go h.announce(userId, online)
+}
+
+// announce sends the presence of the user to the users who share a chat with him
+func (h *Hub) announce(userId bson.ObjectID, online bool) {
+	presence := models.Presence{UserId: userId, Online: online}
+	if !online {
+		// the user is still connected to another instance or has reconnected
+		if h.presence.isOnline(userId) {
+			return
+		}
+		now := time.Now()
+		h.services.UserService.SetLastSeen(userId, now)
+		presence.LastSeen = &now
+	}
+
+	contacts := h.services.ChatService.GetContacts(userId)
+	if len(contacts) == 0 {
+		return
+	}
+	envelope, err := models.NewEnvelope(models.WsPresence, "", presence)
+	if err != nil {
+		log.Printf("failed to encode presence: %s", err)
+		return
+	}
+	h.publish(Event{UserIds: contacts, Envelope: envelope})
+}
+
+func (h *Hub) publishPresence(eventType string, userIds []bson.ObjectID, online bool) {
+	envelope, err := models.NewEnvelope(eventType, "", presencePayload{UserIds: userIds, Online: online})
+	if err != nil {
+		log.Printf("failed to encode %s: %s", eventType, err)
+		return
+	}
+	h.publish(Event{Envelope: envelope})
+}
+
+// publish doesn't block the caller, it may be the loop of the hub.
+// If the bus falls behind, the event is dropped, presence is repaired by the next sync
+func (h *Hub) publish(event Event) {
+	event.Origin = h.instance
+	select {
+	case h.outbox <- event:
+	default:
+		log.Printf("bus: outbox is full, dropping %s", event.Envelope.Type)
+	}
+}
+
+// publishLoop is the single publisher of the instance, e.g. offline after online
+// of a fast reconnect or typing start and stop reach the other instances in order
+func (h *Hub) publishLoop() {
+	for event := range h.outbox {
+		if err := h.bus.Publish(event); err != nil {
+			log.Printf("bus: failed to publish %s: %s", event.Envelope.Type, err)
+		}
+	}
+}
+
+// dispatch handles the client envelope by its type, every envelope with an id gets an ack or an error.
+// It is called from the reader of the connection
+func (h *Hub) dispatch(client *Client, envelope models.Envelope) {
+	if envelope.Version != 0 && envelope.Version != models.ProtocolVersion {
+		h.reply(client, models.NewErrorEnvelope(envelope.Id, models.WsErrUnsupportedVersion, "supported version is 1"))
+		return
+	}
+
+	switch envelope.Type {
+	case models.WsMessageSend:
+		h.sendMessage(client, envelope)
+	case models.WsSync:
+		h.sync(client, envelope)
+	case models.WsRead:
+		h.read(client, envelope)
+	case models.WsTyping:
+		h.typing(client, envelope)
+	case models.WsMessageEdit, models.WsMessageDelete:
+		h.changeMessage(client, envelope)
+	default:
+		h.reply(client, models.NewErrorEnvelope(envelope.Id, models.WsErrUnknownType, "unknown type "+envelope.Type))
+	}
+}
+
+func (h *Hub) sendMessage(client *Client, envelope models.Envelope) {
+	var message models.Message
+	if err := json.Unmarshal(envelope.Payload, &message); err != nil {
+		h.reply(client, models.NewErrorEnvelope(envelope.Id, models.WsErrBadPayload, err.Error()))
+		return
+	}
+
+	message.SenderId = client.UserId
+	if err := h.services.MessageService.Save(&message); err != nil {
+		h.reply(client, models.NewErrorEnvelope(envelope.Id, models.WsErrSaveFailed, err.Error()))
+		return
+	}
+
+	if ack, err := models.NewEnvelope(models.WsAck, envelope.Id, &message); err == nil {
+		h.reply(client, ack)
+	}
+	if err := h.bus.PublishMessage(&message, h.services.ChatService.GetRecipient(&message)); err != nil {
+		log.Printf("bus: failed to publish message %s: %s", message.Id.Hex(), err)
+	}
+}
+
+// sync sends the missed messages to the connection that asked, the ack has the cursor to continue from.
+// The delivery and the cursor are recorded when the messages are written
+func (h *Hub) sync(client *Client, envelope models.Envelope) {
+	var payload models.WsSyncPayload
+	if len(envelope.Payload) > 0 {
+		if err := json.Unmarshal(envelope.Payload, &payload); err != nil {
+			h.reply(client, models.NewErrorEnvelope(envelope.Id, models.WsErrBadPayload, err.Error()))
+			return
+		}
+	}
+
+	messages, more, err := h.services.MessageService.Sync(client.UserId, payload, h.config.WsSyncLimit)
+	if err != nil {
+		h.reply(client, models.NewErrorEnvelope(envelope.Id, models.WsErrSyncFailed, err.Error()))
+		return
+	}
+
+	result := models.WsSyncResult{More: more}
+	for i := range *messages {
+		message := &(*messages)[i]
+		event, err := models.NewEnvelope(models.WsMessageNew, "", message)
+		if err != nil {
+			continue
+		}
+		var cursor *models.MessageAnchor
+		if i == len(*messages)-1 {
+			cursor = &models.MessageAnchor{Id: &message.Id, At: &message.CreatedAt}
+		}
+		if !client.queueMessage(event, message.Id, cursor) {
+			// the rest is sent with the next sync
+			result.More = true
+			break
+		}
+		result.Count++
+		result.LastId = &message.Id
+	}
+
+	if ack, err := models.NewEnvelope(models.WsAck, envelope.Id, result); err == nil {
+		h.reply(client, ack)
+	}
+}
+
+// read moves the read marker of the sender, the receipt goes to the other members
+func (h *Hub) read(client *Client, envelope models.Envelope) {
+	var payload models.WsReadPayload
+	if err := json.Unmarshal(envelope.Payload, &payload); err != nil || payload.ChatId.IsZero() {
+		h.reply(client, models.NewErrorEnvelope(envelope.Id, models.WsErrBadPayload, "chat_id is required"))
+		return
+	}
+
+	_, receipt, err := h.services.ChatService.MarkRead(client.UserId, payload.ChatId, payload.MessageId)
+	if err != nil {
+		h.reply(client, models.NewErrorEnvelope(envelope.Id, models.WsErrReadFailed, err.Error()))
+		return
+	}
+	if ack, err := models.NewEnvelope(models.WsAck, envelope.Id, receipt); err == nil {
+		h.reply(client, ack)
+	}
+}
+
+// typing goes to the other members of the chat, repeated "typing" of the same chat is throttled
+func (h *Hub) typing(client *Client, envelope models.Envelope) {
+	var typing models.Typing
+	if err := json.Unmarshal(envelope.Payload, &typing); err != nil || typing.ChatId.IsZero() {
+		h.reply(client, models.NewErrorEnvelope(envelope.Id, models.WsErrBadPayload, "chat_id is required"))
+		return
+	}
+
+	if typing.Typing && !client.allowTyping(typing.ChatId, h.config.WsTypingLimit) {
+		return
+	}
+	if !typing.Typing {
+		client.resetTyping(typing.ChatId)
+	}
+
+	recipients, err := h.services.ChatService.TypingRecipients(client.UserId, typing.ChatId)
+	if err != nil {
+		h.reply(client, models.NewErrorEnvelope(envelope.Id, models.WsErrBadPayload, err.Error()))
+		return
+	}
+
+	typing.UserId = client.UserId
+	event, err := models.NewEnvelope(models.WsTyping, "", typing)
+	if err != nil {
+		return
+	}
+	h.publish(Event{UserIds: recipients, Envelope: event})
+}
+
+// changeMessage edits or deletes the message, the members get the change from the service
+func (h *Hub) changeMessage(client *Client, envelope models.Envelope) {
+	var change models.WsMessageChange
+	if err := json.Unmarshal(envelope.Payload, &change); err != nil || change.Id.IsZero() {
+		h.reply(client, models.NewErrorEnvelope(envelope.Id, models.WsErrBadPayload, "id is required"))
+		return
+	}
+
+	var status int
+	var message *models.Message
+	var err error
+	if envelope.Type == models.WsMessageEdit {
+		status, message, err = h.services.MessageService.Edit(client.UserId, change.Id, change.Text)
+	} else {
+		status, message, err = h.services.MessageService.Delete(client.UserId, change.Id)
+	}
+	if err != nil {
+		h.reply(client, models.NewErrorEnvelope(envelope.Id, errorCode(status), err.Error()))
+		return
+	}
+
+	if ack, err := models.NewEnvelope(models.WsAck, envelope.Id, message); err == nil {
+		h.reply(client, ack)
+	}
+}
+
+// errorCode converts the status of a service to the code of the error event
+func errorCode(status int) string {
+	switch status {
+	case http.StatusForbidden:
+		return models.WsErrForbidden
+	case http.StatusNotFound:
+		return models.WsErrNotFound
+	case http.StatusConflict:
+		return models.WsErrConflict
+	default:
+		return models.WsErrBadPayload
+	}
+}
+
+// reply puts the envelope in the queue of the sender
+func (h *Hub) reply(client *Client, envelope models.Envelope) {
+	if !client.queue(envelope) {
+		log.Printf("send queue of client %s is full, reply %s is dropped", client.UserId.Hex(), envelope.Type)
+	}
+}
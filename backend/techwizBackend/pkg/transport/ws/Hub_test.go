@@ -0,0 +1,67 @@
+package ws
+
+import (
+	"fmt"
+	"techwizBackend/pkg/config"
+	"techwizBackend/pkg/models"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// recordingBus keeps the published events, a slow bus lets later events catch up with earlier ones
+type recordingBus struct {
+	Bus
+	delay     time.Duration
+	published chan Event
+}
+
+func (b *recordingBus) Publish(event Event) error {
+	time.Sleep(b.delay)
+	b.published <- event
+	return nil
+}
+
+func TestHubPublishKeepsOrder(t *testing.T) {
+	bus := &recordingBus{delay: time.Millisecond, published: make(chan Event, 100)}
+	h := NewHub(nil, &config.Config{WsPresenceSync: time.Minute}, bus)
+	go h.publishLoop()
+
+	userId := bson.NewObjectID()
+	for i := 0; i < 20; i++ {
+		h.publishPresence(busPresence, []bson.ObjectID{userId}, i%2 == 0)
+	}
+
+	for i := 0; i < 20; i++ {
+		select {
+		case event := <-bus.published:
+			want := fmt.Sprintf(`{"user_ids":["%s"],"online":%t}`, userId.Hex(), i%2 == 0)
+			if string(event.Envelope.Payload) != want || event.Origin != h.instance {
+				t.Fatalf("event %d = %s from %s, want %s", i, event.Envelope.Payload, event.Origin, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("event %d is not published", i)
+		}
+	}
+}
+
+func TestHubPublishDoesNotBlock(t *testing.T) {
+	h := NewHub(nil, &config.Config{WsPresenceSync: time.Minute}, &recordingBus{})
+	envelope, _ := models.NewEnvelope(models.WsTyping, "", nil)
+
+	// nothing drains the outbox, the events over its size are dropped
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < cap(h.outbox)+10; i++ {
+			h.publish(Event{Envelope: envelope})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publish blocks on a full outbox")
+	}
+}
@@ -0,0 +1,96 @@
+package ws
+
+import (
+	"context"
+	"log"
+	"techwizBackend/pkg/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// MongoBus uses change streams, so MongoDB must run as a replica set.
+// Chat messages are taken from the inserts to Messages, other events are written to HubEvents
+type MongoBus struct {
+	db         *mongo.Client
+	recipients Recipients
+}
+
+func NewMongoBus(db *mongo.Client, recipients Recipients) *MongoBus {
+	return &MongoBus{db: db, recipients: recipients}
+}
+
+func (b *MongoBus) Publish(event Event) error {
+	event.CreatedAt = time.Now()
+	coll := b.db.Database("TechPower").Collection("HubEvents")
+	_, err := coll.InsertOne(context.TODO(), event)
+	return err
+}
+
+// PublishMessage does nothing, the insert of the message is already in the change stream
+func (b *MongoBus) PublishMessage(*models.Message, []bson.ObjectID) error {
+	return nil
+}
+
+func (b *MongoBus) Run(deliver func(Event)) {
+	go b.watch("Messages", func(raw bson.Raw) {
+		var change struct {
+			Message models.Message `bson:"fullDocument"`
+		}
+		if err := bson.Unmarshal(raw, &change); err != nil {
+			log.Printf("bus: failed to decode message: %s", err)
+			return
+		}
+
+		envelope, err := models.NewEnvelope(models.WsMessageNew, "", &change.Message)
+		if err != nil {
+			log.Printf("bus: failed to encode message: %s", err)
+			return
+		}
+		deliver(Event{UserIds: b.recipients(&change.Message), Envelope: envelope, MessageId: &change.Message.Id})
+	})
+
+	b.watch("HubEvents", func(raw bson.Raw) {
+		var change struct {
+			Event Event `bson:"fullDocument"`
+		}
+		if err := bson.Unmarshal(raw, &change); err != nil {
+			log.Printf("bus: failed to decode event: %s", err)
+			return
+		}
+		deliver(change.Event)
+	})
+}
+
+// watch follows the inserts to the collection, the stream is resumed after an error
+func (b *MongoBus) watch(collection string, handle func(bson.Raw)) {
+	coll := b.db.Database("TechPower").Collection(collection)
+	pipeline := mongo.Pipeline{bson.D{{Key: "$match", Value: bson.M{"operationType": "insert"}}}}
+	var token bson.Raw
+
+	for {
+		opts := options.ChangeStream()
+		if token != nil {
+			opts.SetResumeAfter(token)
+		}
+
+		stream, err := coll.Watch(context.TODO(), pipeline, opts)
+		if err != nil {
+			log.Printf("bus: failed to watch %s: %s", collection, err)
+			time.Sleep(5 * time.Second)
+			continue
+		}
+
+		for stream.Next(context.TODO()) {
+			handle(stream.Current)
+			token = stream.ResumeToken()
+		}
+		if err := stream.Err(); err != nil {
+			log.Printf("bus: stream of %s is broken: %s", collection, err)
+		}
+		_ = stream.Close(context.TODO())
+		time.Sleep(time.Second)
+	}
+}
@@ -0,0 +1,93 @@
+package ws
+
+import (
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Presence event types of the bus, clients get their own presence events
+const (
+	busPresence     = "bus.presence"      // the user connected or disconnected on the instance
+	busPresenceSync = "bus.presence.sync" // all online users of the instance, sent periodically
+)
+
+// presencePayload is the payload of the bus presence events
+type presencePayload struct {
+	UserIds []bson.ObjectID `json:"user_ids"`
+	Online  bool            `json:"online"`
+}
+
+// presence knows the online users of all instances. Users of an instance that stopped
+// without saying goodbye expire after a few missed syncs
+type presence struct {
+	mu     sync.RWMutex
+	local  map[bson.ObjectID]struct{}
+	remote map[string]map[bson.ObjectID]time.Time // instance -> user -> last seen
+	expire time.Duration
+}
+
+func newPresence(expire time.Duration) *presence {
+	return &presence{
+		local:  make(map[bson.ObjectID]struct{}),
+		remote: make(map[string]map[bson.ObjectID]time.Time),
+		expire: expire,
+	}
+}
+
+func (p *presence) setLocal(userId bson.ObjectID, online bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if online {
+		p.local[userId] = struct{}{}
+	} else {
+		delete(p.local, userId)
+	}
+}
+
+func (p *presence) setRemote(instance string, userIds []bson.ObjectID, online bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	users, ok := p.remote[instance]
+	if !ok {
+		users = make(map[bson.ObjectID]time.Time)
+		p.remote[instance] = users
+	}
+	for _, id := range userIds {
+		if online {
+			users[id] = time.Now()
+		} else {
+			delete(users, id)
+		}
+	}
+}
+
+// sync replaces the online users of the instance
+func (p *presence) sync(instance string, userIds []bson.ObjectID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	users := make(map[bson.ObjectID]time.Time, len(userIds))
+	for _, id := range userIds {
+		users[id] = time.Now()
+	}
+	p.remote[instance] = users
+}
+
+func (p *presence) isOnline(userId bson.ObjectID) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if _, ok := p.local[userId]; ok {
+		return true
+	}
+	for _, users := range p.remote {
+		if seen, ok := users[userId]; ok && time.Since(seen) < p.expire {
+			return true
+		}
+	}
+	return false
+}
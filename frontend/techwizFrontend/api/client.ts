@@ -222,7 +222,8 @@ class ApiClient {
     });
   }
 
-  async changeMasterStatus(userId: string, event: 'add' | 'remove', status?: string): Promise<void> {
+  // adminId is required for 'add', the manual tier is recorded with the admin who set it
+  async changeMasterStatus(userId: string, event: 'add' | 'remove', status?: string, adminId?: string): Promise<void> {
     const params = new URLSearchParams({
       id: userId,
       event,
@@ -230,6 +231,9 @@ class ApiClient {
     if (status) {
       params.append('status', status);
     }
+    if (adminId) {
+      params.append('user', adminId);
+    }
     await this.client.patch(`/user/master?${params.toString()}`);
   }
 
@@ -339,12 +343,16 @@ class ApiClient {
     }
   }
 
-  // Получить сообщения чата
-  async getMessages(chatId: string): Promise<any[]> {
-    const response = await this.client.get(`/message?id=${chatId}`);
-    if (Array.isArray(response.data)) return response.data;
-    if (Array.isArray(response.data.messages)) return response.data.messages;
-    return [];
+  // Получить страницу сообщений чата, before - id самого старого загруженного сообщения
+  async getMessages(chatId: string, before?: string): Promise<{ messages: any[], hasMore: boolean }> {
+    const params = new URLSearchParams({ id: chatId });
+    if (before) {
+      params.append('before', before);
+    }
+    const response = await this.client.get(`/message?${params.toString()}`);
+    if (!Array.isArray(response.data?.messages)) return { messages: [], hasMore: false };
+    // страница истории приходит от новых к старым
+    return { messages: [...response.data.messages].reverse(), hasMore: !!response.data.has_more };
   }
 }
 
@@ -2,6 +2,19 @@ import { useState } from "react";
 import { apiClient, Message, Chat as ApiChat } from '@/api/client';
 
 
+// id of the sync request, its ack tells whether more messages are waiting
+export const SYNC_PREFIX = 'sync-';
+
+// sendSync asks for the missed messages, afterId continues the previous sync
+export function sendSync(websocket: WebSocket, afterId?: string) {
+  websocket.send(JSON.stringify({
+    v: 1,
+    type: 'sync',
+    id: SYNC_PREFIX + Date.now().toString(),
+    payload: afterId ? { after_id: afterId } : undefined,
+  }));
+}
+
 export default function useWebSocket(userId: string ) : WebSocket {
     const url = apiClient.getWebSocketUrl()
     const websocket = new WebSocket(`${url}?id=${userId}`)
@@ -11,6 +24,8 @@ export default function useWebSocket(userId: string ) : WebSocket {
     // Обработчик открытия соединения
     websocket.onopen = () => {
       console.log('WebSocket подключен');
+      // сообщения, пропущенные без соединения
+      sendSync(websocket);
     };
 
     // Обработчик ошибок
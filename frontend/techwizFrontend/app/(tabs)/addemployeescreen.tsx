@@ -16,6 +16,7 @@ import { Plus, Search, X, Edit, Trash2, UserCheck } from 'lucide-react-native';
 import { Picker } from '@react-native-picker/picker';
 import RoleGuard from '@/components/RoleGuard';
 import { apiClient, User } from '@/api/client';
+import { useAuth } from '@/contexts/AuthContext';
 import { permissionToRole, roleToPermission, getRoleTitle } from '@/utils/roleUtils';
 
 interface Employee {
@@ -28,6 +29,7 @@ interface Employee {
 }
 
 export default function EmployeeScreen() {
+  const { user } = useAuth();
   const [employees, setEmployees] = useState<Employee[]>([]);
   const [searchQuery, setSearchQuery] = useState('');
   const [isModalVisible, setModalVisible] = useState(false);
@@ -113,7 +115,7 @@ export default function EmployeeScreen() {
   const handleChangeMasterStatus = async () => {
     if (selectedEmployee) {
       try {
-        await apiClient.changeMasterStatus(selectedEmployee.id, 'add', selectedStatus);
+        await apiClient.changeMasterStatus(selectedEmployee.id, 'add', selectedStatus, user?.id);
         await loadEmployees();
         closeStatusModal();
         Alert.alert('Успех', 'Статус мастера изменён');
@@ -159,7 +161,7 @@ export default function EmployeeScreen() {
         const event = selectedEmployee.status === 'active' ? 'remove' : 'add';
         const status = selectedEmployee.status === 'active' ? '' : 'default';
         
-        await apiClient.changeMasterStatus(selectedEmployee.id, event, status);
+        await apiClient.changeMasterStatus(selectedEmployee.id, event, status, user?.id);
         
         // Перезагружаем список сотрудников
         await loadEmployees();
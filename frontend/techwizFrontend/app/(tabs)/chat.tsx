@@ -39,6 +39,8 @@ export default function ChatScreen() {
   const [users, setUsers] = useState<User[]>([]);
   const [isLoadingUsers, setIsLoadingUsers] = useState(false);
   const [refreshing, setRefreshing] = useState(false);
+  const [hasOlderMessages, setHasOlderMessages] = useState(false);
+  const [isLoadingOlder, setIsLoadingOlder] = useState(false);
   
   const selectedCategoryData = chatCategories.find(cat => cat.id === selectedCategory?.id);
   // Безопасно инициализируем chats и chatCategories, чтобы не было ошибок при null
@@ -235,17 +237,34 @@ export default function ChatScreen() {
   // Загрузка сообщений для выбранного чата
   const loadMessagesForChat = async (chatId: string) => {
     try {
-      const messages = await apiClient.getMessages(chatId);
-      // добавил условие чтобы не сохранить null
-      if (messages != null) {
-        setMessages(messages);
-      }
+      const page = await apiClient.getMessages(chatId);
+      setMessages(page.messages);
+      setHasOlderMessages(page.hasMore);
     } catch (error) {
       setMessages([]);
+      setHasOlderMessages(false);
       console.error('Ошибка при загрузке сообщений:', error);
     }
   };
 
+  // Загрузка предыдущей страницы истории перед самым старым сообщением
+  const loadOlderMessages = async () => {
+    if (!selectedCategory || categoryMessages.length === 0 || isLoadingOlder) return;
+    setIsLoadingOlder(true);
+    try {
+      const page = await apiClient.getMessages(selectedCategory.id, categoryMessages[0].id);
+      setMessages((prev) => {
+        const older = page.messages.filter((message) => !prev.some((item) => item.id === message.id));
+        return [...older, ...prev];
+      });
+      setHasOlderMessages(page.hasMore);
+    } catch (error) {
+      console.error('Ошибка при загрузке предыдущих сообщений:', error);
+    } finally {
+      setIsLoadingOlder(false);
+    }
+  };
+
   // Загружать сообщения при выборе чата
   useEffect(() => {
     if (selectedCategory) {
@@ -299,7 +318,19 @@ export default function ChatScreen() {
                 <Text style={styles.emptyChatText}>Начните общение в этой категории</Text>
               </View>
             ) : (
-              categoryMessages.map((message) => (
+              <>
+              {hasOlderMessages && (
+                <TouchableOpacity
+                  style={styles.loadOlderButton}
+                  onPress={loadOlderMessages}
+                  disabled={isLoadingOlder}
+                >
+                  <Text style={styles.loadOlderText}>
+                    {isLoadingOlder ? 'Загрузка...' : 'Загрузить предыдущие сообщения'}
+                  </Text>
+                </TouchableOpacity>
+              )}
+              {categoryMessages.map((message) => (
                 <View
                   key={message.id}
                   style={[
@@ -321,7 +352,8 @@ export default function ChatScreen() {
                     {/* Время сообщения не выводим, если нет такого поля */}
                   </View>
                 </View>
-              ))
+              ))}
+              </>
             )}
           </ScrollView>
 
@@ -752,6 +784,19 @@ const styles = StyleSheet.create({
     paddingBottom: 120,
     flexGrow: 1,
   },
+  loadOlderButton: {
+    alignSelf: 'center',
+    paddingVertical: 8,
+    paddingHorizontal: 16,
+    marginBottom: 12,
+    borderRadius: 16,
+    backgroundColor: '#EFF6FF',
+  },
+  loadOlderText: {
+    fontSize: 14,
+    fontFamily: 'Inter-SemiBold',
+    color: '#2563EB',
+  },
   emptyChat: {
     alignItems: 'center',
     justifyContent: 'center',
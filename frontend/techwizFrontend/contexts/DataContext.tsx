@@ -6,7 +6,7 @@ import { User } from '@/types/user';
 import { Complaint } from '@/types/complaint';
 import { apiClient, User as ApiUser, Category as ApiCategory, Request as ApiRequest, Chat as ApiChat, Message as ApiMessage } from '@/api/client';
 import { useAuth } from './AuthContext';
-import useWebSocket from '@/api/useWebSocket';
+import useWebSocket, { sendSync, SYNC_PREFIX } from '@/api/useWebSocket';
 import { getOrders as getLocalOrders, addOrder as addLocalOrder, deleteOrder as deleteLocalOrder, updateOrder as updateLocalOrder, LocalOrder } from '../data/orders';
 import { getMasters as getLocalMasters, addMaster as addLocalMaster, deleteMaster as deleteLocalMaster, updateMaster as updateLocalMaster, LocalMaster } from '../data/masters';
 
@@ -291,8 +291,19 @@ export function DataProvider({ children }: { children: React.ReactNode }) {
     setWs(ws)
     try {
       ws.onmessage = (event) => {
-        const message = JSON.parse(event.data);
-        setMessages((prev) => [...prev, message]);
+        const envelope = JSON.parse(event.data);
+        if (envelope.type === 'message.new') {
+          // a message can come both live and with sync
+          setMessages((prev) => prev.some((item) => item.id === envelope.payload.id) ? prev : [...prev, envelope.payload]);
+        } else if (envelope.type === 'ack' && envelope.id?.startsWith(SYNC_PREFIX)) {
+          if (envelope.payload?.more && envelope.payload?.last_id) {
+            sendSync(ws, envelope.payload.last_id);
+          }
+        } else if (envelope.type === 'message.edited' || envelope.type === 'message.deleted') {
+          setMessages((prev) => prev.map((item) => (item.id === envelope.payload.id ? envelope.payload : item)));
+        } else if (envelope.type === 'error') {
+          console.error('WebSocket error:', envelope.payload);
+        }
       };
       ws.onerror = (e) => console.error('WebSocket error:', e);
       return () => ws.close();
@@ -389,7 +400,7 @@ export function DataProvider({ children }: { children: React.ReactNode }) {
         recipient_id
       };
       setMessages((prev) => [...prev, newMessage]);
-      ws.send(JSON.stringify(newMessage))
+      ws.send(JSON.stringify({ v: 1, type: 'message.send', id: Date.now().toString(), payload: newMessage }))
     }
   };
 